@@ -119,6 +119,32 @@ func iterMxL(driver neo4j.Driver) {
 	}
 }
 
+// Same as iterMxL but spends some time processing each record, the driver can use that time to
+// fetch the next batch when prefetching.
+func iterMxLWork(driver neo4j.Driver, work time.Duration) {
+	sess := driver.NewSession(neo4j.SessionConfig{})
+	defer sess.Close()
+
+	result, err := sess.Run("MATCH (n:IterMxL) RETURN n", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	num := 0
+	var record *neo4j.Record
+	for result.NextRecord(&record) {
+		num++
+		node := record.Values[0].(neo4j.Node)
+		if len(node.Props) != iterMxLNUMPROPS {
+			panic("Num props differ")
+		}
+		time.Sleep(work)
+	}
+	if num != iterMxLNUMRECS {
+		panic(fmt.Sprintf("Num records differ: %d vs %d", num, iterMxLNUMRECS))
+	}
+}
+
 func iterMxL18(driver neo4j18.Driver) {
 	sess, err := driver.NewSession(neo4j18.SessionConfig{})
 	if err != nil {
@@ -153,6 +179,28 @@ func buildParamsLMap() map[string]interface{} {
 	return m
 }
 
+// Batch of rows as typically sent to UNWIND
+func buildParamsRows() map[string]interface{} {
+	rows := make([]interface{}, 1000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{
+			"id":     i,
+			"name":   fmt.Sprintf("n%d", i),
+			"scores": []float64{1.5, 2.5, 3.5},
+			"meta":   map[string]interface{}{"tags": []string{"a", "b"}, "created": time.Now()},
+		}
+	}
+	return map[string]interface{}{"rows": rows}
+}
+
+func validate(m map[string]interface{}, n int) {
+	for i := 0; i < n; i++ {
+		if err := neo4j.ValidateParameters(m); err != nil {
+			panic(err)
+		}
+	}
+}
+
 func params(driver neo4j.Driver, m map[string]interface{}, n int) {
 	// Use same session for all of n, not part of measurement
 	session := driver.NewSession(neo4j.SessionConfig{})
@@ -291,4 +339,35 @@ func main() {
 	dur, mem = perf(func() { params(driver, m, 10) }, func() { params(driver, m, 1000) })
 	dur18, mem18 = perf(func() { params18(driver18, m, 10) }, func() { params18(driver18, m, 1000) })
 	printRes("paramsL", dur, dur18, mem, mem18)
+
+	// Validation of parameters is compared to running the query with the same parameters,
+	// which includes the validation.
+	rows := buildParamsRows()
+	dur, mem = perf(func() { validate(rows, 10) }, func() { validate(rows, 1000) })
+	durRun, memRun := perf(func() { params(driver, rows, 10) }, func() { params(driver, rows, 1000) })
+	printRes("validateRows", dur, durRun, mem, memRun)
+
+	// Prefetching is compared to the same driver fetching in equally small batches without
+	// prefetching, not to 1.8. Small batches and processing time per record make the round
+	// trips for the next batch show.
+	const (
+		prefetchFetchSize = 50
+		prefetchWork      = 100 * time.Microsecond
+	)
+	driverBatched, err := neo4j.NewDriver(os.Args[1], neo4j.BasicAuth(os.Args[2], os.Args[3], ""), func(conf *neo4j.Config) {
+		conf.FetchSize = prefetchFetchSize
+	})
+	if err != nil {
+		panic(err)
+	}
+	driverPrefetch, err := neo4j.NewDriver(os.Args[1], neo4j.BasicAuth(os.Args[2], os.Args[3], ""), func(conf *neo4j.Config) {
+		conf.FetchSize = prefetchFetchSize
+		conf.PrefetchRatio = 0.5
+	})
+	if err != nil {
+		panic(err)
+	}
+	dur, mem = perf(func() { iterMxLWork(driverPrefetch, prefetchWork) }, func() { iterMxLWork(driverPrefetch, prefetchWork) })
+	durBatched, memBatched := perf(func() { iterMxLWork(driverBatched, prefetchWork) }, func() { iterMxLWork(driverBatched, prefetchWork) })
+	printRes("iterMxLPrefetch", dur, durBatched, mem, memBatched)
 }
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+)
+
+// AuthTokenManager provides the authentication token used by the driver when connecting to the
+// server. Use it with NewDriverWithAuthTokenManager for tokens that change over time, for example
+// short-lived bearer tokens from a Single Sign-On provider.
+//
+// GetAuthToken is called every time the driver connects to a server and should return quickly,
+// a manager that needs to refresh the token should cache it. When GetAuthToken returns a token
+// that differs from the previous one, pooled connections that were authenticated with the
+// previous token are replaced by new connections.
+//
+// OnTokenExpired is called when the server reports that the token has expired, the manager
+// should make sure that the next call to GetAuthToken returns a new token. Transaction
+// functions and acquisition of connections are retried with the new token.
+//
+// Both functions are called concurrently from multiple goroutines.
+type AuthTokenManager interface {
+	GetAuthToken() (AuthToken, error)
+	OnTokenExpired(expired AuthToken)
+}
+
+// Connection pool as seen by the authentication.
+type authPool interface {
+	Expire(expired func(c db.Connection) bool)
+}
+
+// Keeps track of the authentication token used by the driver, either a static token or one
+// provided by an AuthTokenManager.
+type driverAuth struct {
+	manager AuthTokenManager
+	pool    authPool
+	mut     sync.Mutex
+	current map[string]interface{}
+	// Token that the server reported as expired, until the manager provides it again
+	expiredToken map[string]interface{}
+}
+
+func newStaticAuth(token AuthToken) *driverAuth {
+	return &driverAuth{current: token.tokens}
+}
+
+func newManagedAuth(manager AuthTokenManager) *driverAuth {
+	return &driverAuth{manager: manager}
+}
+
+// Returns true if the token can change and it thereby makes sense to retry after the token
+// has expired.
+func (a *driverAuth) rotating() bool {
+	return a != nil && a.manager != nil
+}
+
+// Returns the token to use for a new connection.
+func (a *driverAuth) token() (map[string]interface{}, error) {
+	if a.manager == nil {
+		return a.current, nil
+	}
+	token, err := a.manager.GetAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	a.mut.Lock()
+	rotated := a.current != nil && !reflect.DeepEqual(a.current, token.tokens)
+	if a.expiredToken != nil && reflect.DeepEqual(a.expiredToken, token.tokens) {
+		// The manager considers the token to still be valid, keep connections using it
+		a.expiredToken = nil
+	}
+	a.current = token.tokens
+	a.mut.Unlock()
+	if rotated && a.pool != nil {
+		// Might be called while the pool is connecting and thereby locked, expire the
+		// connections using the previous token in the background. Connections that are
+		// still connecting with the previous token are expired when they reach the pool.
+		go a.pool.Expire(a.expired)
+	}
+	return token.tokens, nil
+}
+
+// Returns true if the connection was authenticated with the token that expired or with another
+// token than the current one.
+func (a *driverAuth) expired(c db.Connection) bool {
+	holder, ok := c.(db.AuthTokenHolder)
+	if !ok {
+		return false
+	}
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	token := holder.AuthToken()
+	return (a.expiredToken != nil && reflect.DeepEqual(token, a.expiredToken)) || !reflect.DeepEqual(token, a.current)
+}
+
+// Called when the server reports that the token has expired.
+func (a *driverAuth) onTokenExpired() {
+	if !a.rotating() {
+		return
+	}
+	a.mut.Lock()
+	expired := AuthToken{tokens: a.current}
+	a.expiredToken = a.current
+	a.mut.Unlock()
+	a.manager.OnTokenExpired(expired)
+	if a.pool != nil {
+		a.pool.Expire(a.expired)
+	}
+}
+
+// Returns the user that the driver currently authenticates as, empty when the token doesn't
+// identify the user like bearer and Kerberos tokens.
+func (a *driverAuth) principal() string {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	principal, _ := a.current[keyPrincipal].(string)
+	return principal
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+)
+
+type authTokenManagerFake struct {
+	mut     sync.Mutex
+	tokens  []AuthToken
+	expired []AuthToken
+}
+
+func (m *authTokenManagerFake) GetAuthToken() (AuthToken, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.tokens[0], nil
+}
+
+func (m *authTokenManagerFake) OnTokenExpired(expired AuthToken) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.expired = append(m.expired, expired)
+	if len(m.tokens) > 1 {
+		m.tokens = m.tokens[1:]
+	}
+}
+
+type authPoolFake struct {
+	expired chan func(c db.Connection) bool
+}
+
+func (p *authPoolFake) Expire(expired func(c db.Connection) bool) {
+	p.expired <- expired
+}
+
+func TestDriverAuth(ot *testing.T) {
+	createAuth := func(tokens ...AuthToken) (*authTokenManagerFake, *authPoolFake, *driverAuth) {
+		manager := &authTokenManagerFake{tokens: tokens}
+		pool := &authPoolFake{expired: make(chan func(c db.Connection) bool, 10)}
+		auth := newManagedAuth(manager)
+		auth.pool = pool
+		return manager, pool, auth
+	}
+
+	connWith := func(token AuthToken) db.Connection {
+		return &ConnFake{Auth: token.tokens}
+	}
+
+	assertToken := func(t *testing.T, auth *driverAuth, expected AuthToken) {
+		t.Helper()
+		token, err := auth.token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(token, expected.tokens) {
+			t.Errorf("Expected token %v but was %v", expected.tokens, token)
+		}
+	}
+
+	ot.Run("Static token", func(t *testing.T) {
+		auth := newStaticAuth(BasicAuth("neo4j", "pass", ""))
+		assertToken(t, auth, BasicAuth("neo4j", "pass", ""))
+		if auth.rotating() {
+			t.Error("Static token should not rotate")
+		}
+		if auth.principal() != "neo4j" {
+			t.Errorf("Unexpected principal %s", auth.principal())
+		}
+		// Should not panic without a manager
+		auth.onTokenExpired()
+	})
+
+	ot.Run("Rotated token expires pooled connections", func(t *testing.T) {
+		manager, pool, auth := createAuth(BearerAuth("first"))
+		assertToken(t, auth, BearerAuth("first"))
+		assertToken(t, auth, BearerAuth("first"))
+		if len(pool.expired) != 0 {
+			t.Fatal("Same token should not expire connections")
+		}
+
+		manager.tokens = []AuthToken{BearerAuth("second")}
+		assertToken(t, auth, BearerAuth("second"))
+		expired := <-pool.expired
+		// Expired by token regardless of when the connection was made
+		if !expired(connWith(BearerAuth("first"))) {
+			t.Error("Expected connection with previous token to expire")
+		}
+		if expired(connWith(BearerAuth("second"))) {
+			t.Error("Expected connection with current token to be kept")
+		}
+	})
+
+	ot.Run("Expired token notifies manager", func(t *testing.T) {
+		manager, pool, auth := createAuth(BearerAuth("first"), BearerAuth("second"))
+		assertToken(t, auth, BearerAuth("first"))
+
+		auth.onTokenExpired()
+		if !reflect.DeepEqual(manager.expired, []AuthToken{BearerAuth("first")}) {
+			t.Errorf("Expected manager to be notified about expired token but was %v", manager.expired)
+		}
+		if len(pool.expired) != 1 {
+			t.Fatalf("Expected pooled connections to expire")
+		}
+		expired := <-pool.expired
+		if !expired(connWith(BearerAuth("first"))) {
+			t.Error("Expected connection with expired token to expire")
+		}
+		assertToken(t, auth, BearerAuth("second"))
+		if expired(connWith(BearerAuth("second"))) {
+			t.Error("Expected connection with new token to be kept")
+		}
+	})
+
+	ot.Run("Expired token provided again keeps new connections", func(t *testing.T) {
+		_, pool, auth := createAuth(BearerAuth("first"))
+		assertToken(t, auth, BearerAuth("first"))
+
+		auth.onTokenExpired()
+		expired := <-pool.expired
+		if !expired(connWith(BearerAuth("first"))) {
+			t.Error("Expected connection with expired token to expire")
+		}
+		// Manager refreshes lazily and provides the same token
+		assertToken(t, auth, BearerAuth("first"))
+		if expired(connWith(BearerAuth("first"))) {
+			t.Error("Expected connection with token provided again to be kept")
+		}
+		if !expired(connWith(BearerAuth("other"))) {
+			t.Error("Expected connection with other token to expire")
+		}
+	})
+
+	ot.Run("Driver requires manager", func(t *testing.T) {
+		_, err := NewDriverWithAuthTokenManager("bolt://localhost:7687", nil)
+		assertUsageError(t, err)
+	})
+}
@@ -20,12 +20,18 @@
 package neo4j
 
 import (
+	"context"
+	"crypto/tls"
 	"crypto/x509"
 	"math"
+	"net"
 	"net/url"
 	"time"
 
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/loadbalance"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/retrypolicy"
 )
 
 // A Config contains options that can be used to customize certain
@@ -38,6 +44,44 @@ type Config struct {
 	// and 'neo4j+s'.
 	RootCAs *x509.CertPool
 
+	// ClientCertificate is presented to the server when it requires clients to authenticate
+	// with a certificate, mutual TLS. Load it with tls.LoadX509KeyPair or tls.X509KeyPair.
+	//
+	// The certificate is used for URI schemes 'bolt+s', 'bolt+ssc', 'neo4j+s' and 'neo4j+ssc'.
+	ClientCertificate *tls.Certificate
+	// GetClientCertificate is called for every new connection and returns the client
+	// certificate to present to the server. Use it instead of ClientCertificate to rotate
+	// certificates without recreating the driver, connections that are already
+	// established keep using the certificate they were created with.
+	//
+	// The function is called concurrently from multiple goroutines and should return
+	// quickly, cache the certificate if loading it is expensive.
+	GetClientCertificate func() (*tls.Certificate, error)
+
+	// ConfigureTls is called before every TLS handshake with the configuration that the
+	// driver has built from RootCAs, the client certificate and the address of the server.
+	// The function can modify the configuration or return a new one, for example to require
+	// a minimum TLS version, restrict cipher suites, pin certificates with
+	// VerifyPeerCertificate, override the server name used for SNI or reload trusted
+	// certificates from disk. Returning an error fails the connection attempt.
+	//
+	// The URI scheme still decides whether the server certificate is verified, the
+	// InsecureSkipVerify setting of the returned configuration is ignored. The function is
+	// not called for URI schemes without encryption.
+	//
+	// The function is called concurrently from multiple goroutines.
+	ConfigureTls func(address string, config *tls.Config) (*tls.Config, error)
+
+	// DialContext, if set, is used to open network connections to servers instead of the
+	// default dialer. Use it to connect through proxies, SSH tunnels or service mesh
+	// sidecars, or to connect to an in-process server in tests. The network is "tcp", or
+	// "unix" for Unix domain sockets, and the address is the resolved address of the
+	// server. TLS and the Bolt handshake are performed on top of the returned connection.
+	//
+	// The context expires after SocketConnectTimeout. SocketKeepalive is not applied to
+	// connections returned by the function.
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+
 	// Logging target the driver will send its log outputs
 	//
 	// Possible to use custom logger (implement log.Logger interface) or
@@ -45,18 +89,51 @@ type Config struct {
 	//
 	// default: No Op Logger (log.Void)
 	Log log.Logger
+	// Listener that will be notified about driver events such as connections
+	// being opened or closed and routing tables being updated. Implement the
+	// event.Listener interface or use event.ListenerFunc and type switch on the
+	// event types defined in the event package.
+	//
+	// default: No Op Listener (event.Void)
+	EventListener event.Listener
 	// Resolver that would be used to resolve initial router address. This may
 	// be useful if you want to provide more than one URL for initial router.
 	// If not specified, the URL provided to NewDriver is used as the initial
-	// router.
+	// router. The resolver is called again every time the driver fails to read
+	// a routing table from all known routers. See NewDNSAddressResolver,
+	// NewSRVAddressResolver and NewStaticAddressResolver for built-in resolvers.
 	//
 	// default: nil
 	AddressResolver ServerAddressResolver
+	// Strategy used to choose among the servers that can serve a request, for
+	// example among the readers in a cluster. The strategy receives statistics
+	// about each candidate server from the connection pool. Use one of the
+	// strategies in the loadbalance package or implement loadbalance.Strategy.
+	// If not specified, servers are chosen based on a penalty calculated from
+	// the number of connections in use, recent connect failures and when the
+	// server was last used.
+	//
+	// default: nil
+	LoadBalancingStrategy loadbalance.Strategy
 	// Maximum amount of time a retriable operation would continue retrying. It
 	// cannot be specified as a negative value.
 	//
 	// default: 30 * time.Second
 	MaxTransactionRetryTime time.Duration
+	// Policy that decides whether transaction functions executed by
+	// Session.ReadTransaction and Session.WriteTransaction are retried and how
+	// long to wait before retrying. MaxTransactionRetryTime is not used when a
+	// policy is set, retries still stop when more connections than
+	// MaxConnectionPoolSize have been lost. When the policy decides not to
+	// retry the first attempt, its error is returned as is. The policy can be
+	// overridden per transaction function by WithTxRetryPolicy. See package
+	// retrypolicy for built-in policies.
+	//
+	// If not specified, retryable errors are retried until
+	// MaxTransactionRetryTime has elapsed.
+	//
+	// default: nil
+	RetryPolicy retrypolicy.Policy
 	// Maximum number of connections per URL to allow on this driver. It
 	// cannot be specified as 0 and negative values are interpreted as
 	// math.MaxInt32.
@@ -68,6 +145,67 @@ type Config struct {
 	//
 	// default: 1 * time.Hour
 	MaxConnectionLifetime time.Duration
+	// Maximum amount of time a pooled connection may stay idle before it is
+	// closed by the driver in the background. Idle connections kept open due to
+	// MinIdleConnectionsPerServer are not closed. Values less than or equal to 0
+	// disables the idle time check. The background maintenance that is started
+	// by this, MinIdleConnectionsPerServer or RoutingTableRefreshAhead also
+	// closes idle connections to servers that are no longer in any routing table.
+	//
+	// default: 0
+	MaxConnectionIdleTime time.Duration
+	// Minimum number of idle connections that the driver tries to keep open to
+	// each known reader and writer in the routing tables, or to the server when
+	// not routing. Connections are created in the background and the number of
+	// connections is still limited by MaxConnectionPoolSize. Zero disables the
+	// background creation of idle connections. It cannot be specified as a
+	// negative value or larger than MaxConnectionPoolSize.
+	//
+	// default: 0
+	MinIdleConnectionsPerServer int
+	// Routing tables of databases that have been used since their last refresh
+	// are refreshed in the background when they are about to expire within this
+	// amount of time. This avoids that a request has to wait for a new routing
+	// table to be read once the table has expired. Values less than or equal to
+	// 0 disable background refresh. Only applies to routing drivers.
+	//
+	// default: 0
+	RoutingTableRefreshAhead time.Duration
+	// Amount of time after expiry that a routing table is still used when
+	// reading a new routing table fails. Values less than or equal to 0 means
+	// that expired routing tables are never used. Routing tables that have been
+	// invalidated due to failures are never used. Only applies to routing
+	// drivers.
+	//
+	// default: 0
+	StaleRoutingTableGracePeriod time.Duration
+	// Amount of time that the resolved home database of a user is remembered.
+	// Sessions created without a database name need to know the name of the
+	// home database, remembering it avoids an extra round trip to the server
+	// for every such session. The home database is forgotten when the routing
+	// table for it changes or when the server reports that the database can
+	// not be found. Values less than or equal to 0 disable the cache. Only
+	// applies to routing drivers authenticating with a token that names the
+	// user, like basic authentication, the cache is not used for bearer and
+	// Kerberos tokens.
+	//
+	// default: 0
+	HomeDatabaseCacheTTL time.Duration
+	// Number of consecutive failures, failed connects, queries failing due to
+	// the server being unable to serve them or connections that are found
+	// dead, after which the circuit breaker for a server opens. No
+	// connections are made to a server while its circuit breaker is open and
+	// routing drivers skip the server if there are other servers to choose from.
+	// Values less than or equal to 0 disable circuit breaking.
+	//
+	// default: 0
+	CircuitBreakerThreshold int
+	// Amount of time a circuit breaker stays open before a single probe
+	// connection is let through to the server. The breaker closes if the probe
+	// succeeds and opens again if it fails.
+	//
+	// default: 30 * time.Second
+	CircuitBreakerOpenDuration time.Duration
 	// Maximum amount of time to either acquire an idle connection from the pool
 	// or create a new connection (when the pool is not full). Negative values
 	// result in an infinite wait time where 0 value results in no timeout which
@@ -98,6 +236,53 @@ type Config struct {
 	// To turn off fetching in batches and always fetch everything, set FetchSize to FetchAll.
 	// If a single large result is to be retrieved this is the most performant setting.
 	FetchSize int
+	// Lets results read ahead by requesting the next batch of records from the server once
+	// this fraction of the current batch has been consumed, instead of when the batch is
+	// exhausted. The rest of the current batch is then buffered, so at most FetchSize records
+	// are held per result, and the round trip of the request overlaps with the processing of
+	// the buffered records. This pays off on high latency links when every record takes some
+	// time to process. Must be greater than or equal to 0 and less than 1, 0 turns read ahead
+	// off. Ignored when FetchSize is FetchAll or the protocol doesn't support fetching in
+	// batches.
+	//
+	// default: 0
+	PrefetchRatio float64
+	// Logs every notification with WARNING severity, like the use of deprecated syntax, through
+	// Log together with the query and the position in the query. Notifications are logged when
+	// the result reaches its summary, either by iterating all records or by consuming the
+	// result, or when a result that hasn't been consumed is buffered or discarded by the
+	// session or the transaction.
+	//
+	// default: false
+	LogNotifications bool
+	// Queries that take longer than this are reported to SlowQueryLogger, both the timings
+	// reported by the server and the time measured by the driver from the call to Run are
+	// compared to the threshold. Queries that fail after exceeding the threshold are reported
+	// with the error. The time spent on previous attempts of a transaction function is
+	// reported separately. Values less than or equal to 0 disable the slow query log.
+	//
+	// default: 0
+	SlowQueryThreshold time.Duration
+	// Receives queries that exceeded SlowQueryThreshold. Parameter values are never passed to
+	// the logger, only their types.
+	//
+	// If not specified, slow queries are logged as warnings through Log.
+	//
+	// default: nil
+	SlowQueryLogger SlowQueryLogger
+	// Metadata attached to all transactions of all sessions, like the name of the application,
+	// to make them show up attributed in the query log of the server. It is merged with
+	// SessionConfig.DefaultTxMetadata and with the metadata given by WithTxMetadata, which
+	// take precedence.
+	//
+	// default: nil
+	DefaultTxMetadata map[string]interface{}
+	// Timeout of all transactions that are not given a timeout by
+	// SessionConfig.DefaultTxTimeout or WithTxTimeout. It cannot be specified as a
+	// negative value, 0 means that the timeout configured on the server is used.
+	//
+	// default: 0
+	DefaultTxTimeout time.Duration
 }
 
 func defaultConfig() *Config {
@@ -109,6 +294,7 @@ func defaultConfig() *Config {
 		ConnectionAcquisitionTimeout: 1 * time.Minute,
 		SocketConnectTimeout:         5 * time.Second,
 		SocketKeepalive:              true,
+		CircuitBreakerOpenDuration:   30 * time.Second,
 		RootCAs:                      nil,
 		UserAgent:                    UserAgent,
 		FetchSize:                    FetchDefault,
@@ -116,11 +302,26 @@ func defaultConfig() *Config {
 }
 
 func validateAndNormaliseConfig(config *Config) error {
+	// Client Certificate
+	if config.ClientCertificate != nil && config.GetClientCertificate != nil {
+		return &UsageError{Message: "Only one of client certificate and client certificate callback can be set"}
+	}
+
 	// Max Transaction Retry Time
 	if config.MaxTransactionRetryTime < 0 {
 		return &UsageError{Message: "Maximum transaction retry time cannot be smaller than 0"}
 	}
 
+	// Default Transaction Timeout
+	if config.DefaultTxTimeout < 0 {
+		return &UsageError{Message: "Default transaction timeout cannot be smaller than 0"}
+	}
+
+	// Prefetch Ratio
+	if config.PrefetchRatio < 0 || config.PrefetchRatio >= 1 {
+		return &UsageError{Message: "Prefetch ratio must be greater than or equal to 0 and less than 1"}
+	}
+
 	// Max Connection Pool Size
 	if config.MaxConnectionPoolSize == 0 {
 		return &UsageError{Message: "Maximum connection pool cannot be 0"}
@@ -130,6 +331,44 @@ func validateAndNormaliseConfig(config *Config) error {
 		config.MaxConnectionPoolSize = math.MaxInt32
 	}
 
+	// Max Connection Idle Time
+	if config.MaxConnectionIdleTime < 0 {
+		config.MaxConnectionIdleTime = 0
+	}
+
+	// Min Idle Connections Per Server
+	if config.MinIdleConnectionsPerServer < 0 {
+		return &UsageError{Message: "Minimum idle connections per server cannot be smaller than 0"}
+	}
+
+	if config.MinIdleConnectionsPerServer > config.MaxConnectionPoolSize {
+		return &UsageError{Message: "Minimum idle connections per server cannot be larger than maximum connection pool size"}
+	}
+
+	// Routing Table Refresh Ahead
+	if config.RoutingTableRefreshAhead < 0 {
+		config.RoutingTableRefreshAhead = 0
+	}
+
+	// Stale Routing Table Grace Period
+	if config.StaleRoutingTableGracePeriod < 0 {
+		config.StaleRoutingTableGracePeriod = 0
+	}
+
+	// Home Database Cache TTL
+	if config.HomeDatabaseCacheTTL < 0 {
+		config.HomeDatabaseCacheTTL = 0
+	}
+
+	// Circuit Breaker
+	if config.CircuitBreakerThreshold < 0 {
+		config.CircuitBreakerThreshold = 0
+	}
+
+	if config.CircuitBreakerOpenDuration < 0 {
+		config.CircuitBreakerOpenDuration = 0
+	}
+
 	// Max Connection Lifetime
 	if config.MaxConnectionLifetime < 0 {
 		config.MaxConnectionLifetime = 0
@@ -168,7 +407,7 @@ func newServerAddressURL(hostname string, port string) *url.URL {
 
 	hostAndPort := hostname
 	if port != "" {
-		hostAndPort = hostAndPort + ":" + port
+		hostAndPort = net.JoinHostPort(hostname, port)
 	}
 
 	return &url.URL{Host: hostAndPort}
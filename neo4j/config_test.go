@@ -20,6 +20,7 @@
 package neo4j
 
 import (
+	"crypto/tls"
 	"math"
 	"testing"
 	"time"
@@ -51,6 +52,10 @@ func TestDefaultConfig(t *testing.T) {
 	if config.SocketKeepalive != true {
 		t.Errorf("should have socket keep alive enabled by default")
 	}
+
+	if config.CircuitBreakerOpenDuration != 30*time.Second {
+		t.Errorf("should have circuit breaker open duration set to 30 seconds by default")
+	}
 }
 
 func TestValidateAndNormaliseConfig(rt *testing.T) {
@@ -65,6 +70,28 @@ func TestValidateAndNormaliseConfig(rt *testing.T) {
 		}
 	})
 
+	rt.Run("DefaultTxTimeout less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.DefaultTxTimeout = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err == nil {
+			t.Errorf("DefaultTxTimeout is less than 0 but never returned an error")
+		}
+	})
+
+	rt.Run("PrefetchRatio out of range", func(t *testing.T) {
+		for _, ratio := range []float64{-0.5, 1, 2} {
+			config := defaultConfig()
+
+			config.PrefetchRatio = ratio
+			err := validateAndNormaliseConfig(config)
+			if err == nil {
+				t.Errorf("PrefetchRatio is %v but never returned an error", ratio)
+			}
+		}
+	})
+
 	rt.Run("MaxConnectionPoolSize equals zero", func(t *testing.T) {
 		config := defaultConfig()
 
@@ -113,4 +140,101 @@ func TestValidateAndNormaliseConfig(rt *testing.T) {
 			t.Errorf("SocketConnectTimeout should be set to (0 * time.Nanosecond) when negative")
 		}
 	})
+	rt.Run("MaxConnectionIdleTime less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.MaxConnectionIdleTime = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err != nil {
+			t.Errorf("MaxConnectionIdleTime is negative but returned an error")
+		}
+		if config.MaxConnectionIdleTime != 0 {
+			t.Errorf("MaxConnectionIdleTime should be set to 0 when negative")
+		}
+	})
+
+	rt.Run("RoutingTableRefreshAhead less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.RoutingTableRefreshAhead = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err != nil {
+			t.Errorf("RoutingTableRefreshAhead is negative but returned an error")
+		}
+		if config.RoutingTableRefreshAhead != 0 {
+			t.Errorf("RoutingTableRefreshAhead should be set to 0 when negative")
+		}
+	})
+
+	rt.Run("StaleRoutingTableGracePeriod less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.StaleRoutingTableGracePeriod = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err != nil {
+			t.Errorf("StaleRoutingTableGracePeriod is negative but returned an error")
+		}
+		if config.StaleRoutingTableGracePeriod != 0 {
+			t.Errorf("StaleRoutingTableGracePeriod should be set to 0 when negative")
+		}
+	})
+
+	rt.Run("HomeDatabaseCacheTTL less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.HomeDatabaseCacheTTL = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err != nil {
+			t.Errorf("HomeDatabaseCacheTTL is negative but returned an error")
+		}
+		if config.HomeDatabaseCacheTTL != 0 {
+			t.Errorf("HomeDatabaseCacheTTL should be set to 0 when negative")
+		}
+	})
+
+	rt.Run("CircuitBreakerThreshold less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.CircuitBreakerThreshold = -1
+		config.CircuitBreakerOpenDuration = -1 * time.Second
+		err := validateAndNormaliseConfig(config)
+		if err != nil {
+			t.Errorf("CircuitBreakerThreshold is negative but returned an error")
+		}
+		if config.CircuitBreakerThreshold != 0 || config.CircuitBreakerOpenDuration != 0 {
+			t.Errorf("CircuitBreakerThreshold and CircuitBreakerOpenDuration should be set to 0 when negative")
+		}
+	})
+
+	rt.Run("MinIdleConnectionsPerServer less than zero", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.MinIdleConnectionsPerServer = -1
+		err := validateAndNormaliseConfig(config)
+		if err == nil {
+			t.Errorf("MinIdleConnectionsPerServer is less than 0 but never returned an error")
+		}
+	})
+
+	rt.Run("MinIdleConnectionsPerServer larger than MaxConnectionPoolSize", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.MaxConnectionPoolSize = 10
+		config.MinIdleConnectionsPerServer = 11
+		err := validateAndNormaliseConfig(config)
+		if err == nil {
+			t.Errorf("MinIdleConnectionsPerServer is larger than MaxConnectionPoolSize but never returned an error")
+		}
+	})
+
+	rt.Run("Both ClientCertificate and GetClientCertificate", func(t *testing.T) {
+		config := defaultConfig()
+
+		config.ClientCertificate = &tls.Certificate{}
+		config.GetClientCertificate = func() (*tls.Certificate, error) { return nil, nil }
+		err := validateAndNormaliseConfig(config)
+		if err == nil {
+			t.Errorf("Both ClientCertificate and GetClientCertificate are set but never returned an error")
+		}
+	})
 }
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cypher builds Cypher queries from clauses without string concatenation. Labels,
+// relationship types, property keys and variables are escaped with backticks and values are
+// always sent as parameters, which makes it safe to use input from users in both.
+//
+//	cypher, params, err := cypher.New().
+//		Match(cypher.Node("p", "Person").Out(cypher.Rel("", "ACTED_IN"), cypher.Node("m", label))).
+//		Where(cypher.Eq(cypher.Prop("p", "name"), name)).
+//		Return(cypher.Prop("m", "title")).
+//		Build()
+//	result, err := session.Run(cypher, params)
+package cypher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Query is a Cypher query under construction, clauses are rendered in the order they are
+// added.
+type Query struct {
+	clauses []string
+	params  map[string]interface{}
+	err     error
+}
+
+// New returns an empty query.
+func New() *Query {
+	return &Query{params: map[string]interface{}{}}
+}
+
+// Build returns the query and its parameters as taken by Session.Run and Transaction.Run.
+// Returns an error if any part of the query is invalid.
+func (q *Query) Build() (string, map[string]interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	if len(q.clauses) == 0 {
+		return "", nil, errors.New("Query is empty")
+	}
+	return strings.Join(q.clauses, "\n"), q.params, nil
+}
+
+// Adds a value as parameter and returns the reference to it.
+func (q *Query) param(value interface{}) string {
+	name := fmt.Sprintf("p%d", len(q.params))
+	q.params[name] = value
+	return "$" + name
+}
+
+func (q *Query) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+func (q *Query) clause(keyword string, parts []string, separator string) *Query {
+	q.clauses = append(q.clauses, keyword+" "+strings.Join(parts, separator))
+	return q
+}
+
+func (q *Query) patterns(keyword string, patterns []Pattern) *Query {
+	if len(patterns) == 0 {
+		q.setErr(fmt.Errorf("%s requires at least one pattern", keyword))
+		return q
+	}
+	parts := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		parts[i] = pattern.render(q)
+	}
+	return q.clause(keyword, parts, ", ")
+}
+
+func (q *Query) expressions(keyword string, exprs []Expr) *Query {
+	if len(exprs) == 0 {
+		q.setErr(fmt.Errorf("%s requires at least one expression", keyword))
+		return q
+	}
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = expr.render(q)
+	}
+	return q.clause(keyword, parts, ", ")
+}
+
+// Match adds a MATCH clause.
+func (q *Query) Match(patterns ...Pattern) *Query {
+	return q.patterns("MATCH", patterns)
+}
+
+// OptionalMatch adds an OPTIONAL MATCH clause.
+func (q *Query) OptionalMatch(patterns ...Pattern) *Query {
+	return q.patterns("OPTIONAL MATCH", patterns)
+}
+
+// Create adds a CREATE clause.
+func (q *Query) Create(patterns ...Pattern) *Query {
+	return q.patterns("CREATE", patterns)
+}
+
+// Merge adds a MERGE clause.
+func (q *Query) Merge(pattern Pattern) *Query {
+	return q.patterns("MERGE", []Pattern{pattern})
+}
+
+// Where adds a WHERE clause, the conditions are combined with AND.
+func (q *Query) Where(conditions ...Expr) *Query {
+	if len(conditions) == 0 {
+		q.setErr(errors.New("WHERE requires at least one condition"))
+		return q
+	}
+	return q.clause("WHERE", []string{And(conditions...).render(q)}, "")
+}
+
+// Unwind adds an UNWIND clause over a list, the list is sent as parameter unless it is an
+// expression.
+func (q *Query) Unwind(list interface{}, variable string) *Query {
+	return q.clause("UNWIND", []string{operand(list).render(q) + " AS " + q.escape(variable)}, "")
+}
+
+// Set adds a SET clause that sets a property to a value, the value is sent as parameter
+// unless it is an expression.
+func (q *Query) Set(property PropertyExpr, value interface{}) *Query {
+	return q.clause("SET", []string{property.render(q) + " = " + operand(value).render(q)}, "")
+}
+
+// Delete adds a DELETE clause, DETACH DELETE when detach is set.
+func (q *Query) Delete(detach bool, variables ...string) *Query {
+	keyword := "DELETE"
+	if detach {
+		keyword = "DETACH DELETE"
+	}
+	return q.expressions(keyword, vars(variables))
+}
+
+// With adds a WITH clause.
+func (q *Query) With(items ...Expr) *Query {
+	return q.expressions("WITH", items)
+}
+
+// Return adds a RETURN clause.
+func (q *Query) Return(items ...Expr) *Query {
+	return q.expressions("RETURN", items)
+}
+
+// ReturnDistinct adds a RETURN DISTINCT clause.
+func (q *Query) ReturnDistinct(items ...Expr) *Query {
+	return q.expressions("RETURN DISTINCT", items)
+}
+
+// OrderBy adds an ORDER BY clause, use Desc for descending order.
+func (q *Query) OrderBy(items ...Expr) *Query {
+	return q.expressions("ORDER BY", items)
+}
+
+// Skip adds a SKIP clause.
+func (q *Query) Skip(n int64) *Query {
+	return q.clause("SKIP", []string{q.param(n)}, "")
+}
+
+// Limit adds a LIMIT clause.
+func (q *Query) Limit(n int64) *Query {
+	return q.clause("LIMIT", []string{q.param(n)}, "")
+}
+
+func vars(variables []string) []Expr {
+	exprs := make([]Expr, len(variables))
+	for i, variable := range variables {
+		exprs[i] = Var(variable)
+	}
+	return exprs
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cypher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEscapeName(t *testing.T) {
+	cases := map[string]string{
+		"Person":           "`Person`",
+		"has space":        "`has space`",
+		"a`b":              "`a``b`",
+		"`) DETACH DELETE": "```) DETACH DELETE`",
+		"x\\u0060y":        "`x``y`",
+		"":                 "``",
+	}
+	for name, expected := range cases {
+		if escaped := EscapeName(name); escaped != expected {
+			t.Errorf("Escaped %q to %s but expected %s", name, escaped, expected)
+		}
+	}
+}
+
+func assertQuery(t *testing.T, q *Query, expectedCypher string, expectedParams map[string]interface{}) {
+	t.Helper()
+	cypher, params, err := q.Build()
+	if err != nil {
+		t.Fatalf("Should build query but failed: %s", err)
+	}
+	if cypher != expectedCypher {
+		t.Errorf("Expected query:\n%s\nbut was:\n%s", expectedCypher, cypher)
+	}
+	if !reflect.DeepEqual(params, expectedParams) {
+		t.Errorf("Expected params %v but were %v", expectedParams, params)
+	}
+}
+
+func TestBuild(ot *testing.T) {
+	ot.Run("Match where return", func(t *testing.T) {
+		q := New().
+			Match(Node("p", "Person").Out(Rel("", "ACTED_IN"), Node("m", "Movie"))).
+			Where(Eq(Prop("p", "name"), "Keanu"), Gt(Prop("m", "released"), 1999)).
+			Return(Prop("m", "title"), As(Count(Var("p")), "actors")).
+			OrderBy(Desc(Var("actors"))).
+			Skip(5).
+			Limit(10)
+		assertQuery(t, q,
+			"MATCH (`p`:`Person`)-[:`ACTED_IN`]->(`m`:`Movie`)\n"+
+				"WHERE (`p`.`name` = $p0) AND (`m`.`released` > $p1)\n"+
+				"RETURN `m`.`title`, count(`p`) AS `actors`\n"+
+				"ORDER BY `actors` DESC\n"+
+				"SKIP $p2\n"+
+				"LIMIT $p3",
+			map[string]interface{}{"p0": "Keanu", "p1": 1999, "p2": int64(5), "p3": int64(10)})
+	})
+
+	ot.Run("Injection in identifiers is escaped", func(t *testing.T) {
+		label := "Person`) DETACH DELETE (x"
+		q := New().Match(Node("n", label)).Return(Var("n"))
+		assertQuery(t, q, "MATCH (`n`:`Person``) DETACH DELETE (x`)\nRETURN `n`", map[string]interface{}{})
+	})
+
+	ot.Run("Merge with properties and set", func(t *testing.T) {
+		q := New().
+			Merge(Node("p", "Person").Props(map[string]interface{}{"name": "Ann", "born": 1970})).
+			Set(Prop("p", "last seen"), Call("datetime")).
+			Set(Prop("p", "visits"), 3)
+		assertQuery(t, q,
+			"MERGE (`p`:`Person` {`born`: $p0, `name`: $p1})\n"+
+				"SET `p`.`last seen` = datetime()\n"+
+				"SET `p`.`visits` = $p2",
+			map[string]interface{}{"p0": 1970, "p1": "Ann", "p2": 3})
+	})
+
+	ot.Run("Unwind and create relationships", func(t *testing.T) {
+		ids := []interface{}{1, 2}
+		q := New().
+			Unwind(ids, "id").
+			Match(Node("a").Props(map[string]interface{}{"id": Var("id")}), Node("b", "Hub")).
+			Create(Node("a").In(Rel("r", "LINKS", "REFERS").Props(map[string]interface{}{"w": 1.5}), Node("b"))).
+			Where(Or(IsNull(Prop("a", "x")), Not(In(Prop("a", "x"), []string{"y"}))))
+		assertQuery(t, q,
+			"UNWIND $p0 AS `id`\n"+
+				"MATCH (`a` {`id`: `id`}), (`b`:`Hub`)\n"+
+				"CREATE (`a`)<-[`r`:`LINKS`|`REFERS` {`w`: $p1}]-(`b`)\n"+
+				"WHERE (`a`.`x` IS NULL) OR (NOT (`a`.`x` IN $p2))",
+			map[string]interface{}{"p0": ids, "p1": 1.5, "p2": []string{"y"}})
+	})
+
+	ot.Run("Invalid function name", func(t *testing.T) {
+		_, _, err := New().Return(Call("count(*) //", Var("n"))).Build()
+		if err == nil {
+			t.Error("Should fail on invalid function name")
+		}
+	})
+
+	ot.Run("Empty identifier", func(t *testing.T) {
+		queries := map[string]*Query{
+			"label":        New().Match(Node("n", "")).Return(Var("n")),
+			"type":         New().Match(Node("a").Out(Rel("r", "KNOWS", ""), Node("b"))).Return(Var("r")),
+			"property key": New().Match(Node("n").Props(map[string]interface{}{"": 1})).Return(Var("n")),
+			"variable":     New().Match(Node("n")).Return(Prop("", "name")),
+			"alias":        New().Match(Node("n")).Return(As(Var("n"), "")),
+		}
+		for name, q := range queries {
+			if _, _, err := q.Build(); err == nil {
+				t.Errorf("Should fail on empty %s", name)
+			}
+		}
+	})
+
+	ot.Run("Empty query", func(t *testing.T) {
+		_, _, err := New().Build()
+		if err == nil {
+			t.Error("Should fail on empty query")
+		}
+	})
+
+	ot.Run("Clause without items", func(t *testing.T) {
+		_, _, err := New().Match(Node("n")).Return().Build()
+		if err == nil {
+			t.Error("Should fail on RETURN without items")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cypher
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var functionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// EscapeName quotes a label, relationship type, property key or variable with backticks so
+// that it can be used as an identifier in Cypher regardless of its content. Backticks in the
+// name are doubled, Unicode escapes of backticks are treated as backticks since Cypher
+// interprets them as such. The name must not be empty since Cypher has no empty identifiers.
+func EscapeName(name string) string {
+	name = strings.ReplaceAll(name, "\\u0060", "`")
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Escapes the name for use in the query, fails the query if the name is empty.
+func (q *Query) escape(name string) string {
+	if name == "" {
+		q.setErr(errors.New("Label, relationship type, property key or variable is empty"))
+	}
+	return EscapeName(name)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cypher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a Cypher expression.
+type Expr interface {
+	render(q *Query) string
+}
+
+type exprFunc func(q *Query) string
+
+func (f exprFunc) render(q *Query) string {
+	return f(q)
+}
+
+// Returns the value as expression, values that aren't expressions are sent as parameters.
+func operand(value interface{}) Expr {
+	if expr, isExpr := value.(Expr); isExpr {
+		return expr
+	}
+	return Param(value)
+}
+
+// Var refers to a variable.
+func Var(name string) Expr {
+	return exprFunc(func(q *Query) string { return q.escape(name) })
+}
+
+// PropertyExpr refers to a property of a variable.
+type PropertyExpr struct {
+	variable string
+	key      string
+}
+
+func (p PropertyExpr) render(q *Query) string {
+	return q.escape(p.variable) + "." + q.escape(p.key)
+}
+
+// Prop refers to a property of a variable.
+func Prop(variable, key string) PropertyExpr {
+	return PropertyExpr{variable: variable, key: key}
+}
+
+// Param sends the value as parameter.
+func Param(value interface{}) Expr {
+	return exprFunc(func(q *Query) string { return q.param(value) })
+}
+
+// As names the result of an expression in RETURN and WITH.
+func As(expr Expr, alias string) Expr {
+	return exprFunc(func(q *Query) string { return expr.render(q) + " AS " + q.escape(alias) })
+}
+
+// Desc orders by the expression in descending order.
+func Desc(expr Expr) Expr {
+	return exprFunc(func(q *Query) string { return expr.render(q) + " DESC" })
+}
+
+// Call calls a function, like count or toLower. Arguments that aren't expressions are sent
+// as parameters. The name of the function must be a plain, optionally namespaced, identifier.
+func Call(function string, args ...interface{}) Expr {
+	return exprFunc(func(q *Query) string {
+		if !functionName.MatchString(function) {
+			q.setErr(fmt.Errorf("Invalid function name %q", function))
+		}
+		parts := make([]string, len(args))
+		for i, arg := range args {
+			parts[i] = operand(arg).render(q)
+		}
+		return function + "(" + strings.Join(parts, ", ") + ")"
+	})
+}
+
+// Count counts the values of an expression.
+func Count(expr Expr) Expr {
+	return Call("count", expr)
+}
+
+func binary(a interface{}, operator string, b interface{}) Expr {
+	return exprFunc(func(q *Query) string {
+		return operand(a).render(q) + " " + operator + " " + operand(b).render(q)
+	})
+}
+
+// Eq compares for equality, operands that aren't expressions are sent as parameters.
+func Eq(a, b interface{}) Expr { return binary(a, "=", b) }
+
+// Ne compares for inequality.
+func Ne(a, b interface{}) Expr { return binary(a, "<>", b) }
+
+// Lt compares with less than.
+func Lt(a, b interface{}) Expr { return binary(a, "<", b) }
+
+// Lte compares with less than or equal.
+func Lte(a, b interface{}) Expr { return binary(a, "<=", b) }
+
+// Gt compares with greater than.
+func Gt(a, b interface{}) Expr { return binary(a, ">", b) }
+
+// Gte compares with greater than or equal.
+func Gte(a, b interface{}) Expr { return binary(a, ">=", b) }
+
+// In checks if a is an element of the list b.
+func In(a, b interface{}) Expr { return binary(a, "IN", b) }
+
+// StartsWith checks if the string a starts with b.
+func StartsWith(a, b interface{}) Expr { return binary(a, "STARTS WITH", b) }
+
+// EndsWith checks if the string a ends with b.
+func EndsWith(a, b interface{}) Expr { return binary(a, "ENDS WITH", b) }
+
+// Contains checks if the string a contains b.
+func Contains(a, b interface{}) Expr { return binary(a, "CONTAINS", b) }
+
+// IsNull checks if the expression is null.
+func IsNull(expr Expr) Expr {
+	return exprFunc(func(q *Query) string { return expr.render(q) + " IS NULL" })
+}
+
+// IsNotNull checks if the expression is not null.
+func IsNotNull(expr Expr) Expr {
+	return exprFunc(func(q *Query) string { return expr.render(q) + " IS NOT NULL" })
+}
+
+// Not negates a condition.
+func Not(condition Expr) Expr {
+	return exprFunc(func(q *Query) string { return "NOT (" + condition.render(q) + ")" })
+}
+
+func combine(operator string, conditions []Expr) Expr {
+	return exprFunc(func(q *Query) string {
+		if len(conditions) == 1 {
+			return conditions[0].render(q)
+		}
+		parts := make([]string, len(conditions))
+		for i, condition := range conditions {
+			parts[i] = "(" + condition.render(q) + ")"
+		}
+		return strings.Join(parts, " "+operator+" ")
+	})
+}
+
+// And combines conditions that must all be true.
+func And(conditions ...Expr) Expr { return combine("AND", conditions) }
+
+// Or combines conditions of which at least one must be true.
+func Or(conditions ...Expr) Expr { return combine("OR", conditions) }
@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cypher
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pattern is a node or path pattern used in MATCH, CREATE and MERGE.
+type Pattern interface {
+	render(q *Query) string
+}
+
+// NodePattern matches nodes by labels and properties.
+type NodePattern struct {
+	variable string
+	labels   []string
+	props    map[string]interface{}
+}
+
+// Node returns a pattern for nodes with all of the labels, the variable can be empty.
+func Node(variable string, labels ...string) *NodePattern {
+	return &NodePattern{variable: variable, labels: labels}
+}
+
+// Props requires the node to have the properties, the values are sent as parameters.
+func (n *NodePattern) Props(props map[string]interface{}) *NodePattern {
+	n.props = props
+	return n
+}
+
+func (n *NodePattern) render(q *Query) string {
+	var b strings.Builder
+	b.WriteString("(")
+	if n.variable != "" {
+		b.WriteString(q.escape(n.variable))
+	}
+	for _, label := range n.labels {
+		b.WriteString(":" + q.escape(label))
+	}
+	b.WriteString(renderProps(q, n.props))
+	b.WriteString(")")
+	return b.String()
+}
+
+// Out returns a path from the node over an outgoing relationship to another node.
+func (n *NodePattern) Out(rel *RelPattern, to *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).Out(rel, to)
+}
+
+// In returns a path from the node over an incoming relationship to another node.
+func (n *NodePattern) In(rel *RelPattern, to *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).In(rel, to)
+}
+
+// Both returns a path from the node over a relationship in either direction to another node.
+func (n *NodePattern) Both(rel *RelPattern, to *NodePattern) *PathPattern {
+	return (&PathPattern{start: n}).Both(rel, to)
+}
+
+// RelPattern matches relationships by type and properties.
+type RelPattern struct {
+	variable string
+	types    []string
+	props    map[string]interface{}
+}
+
+// Rel returns a pattern for relationships of any of the types, the variable can be empty.
+func Rel(variable string, types ...string) *RelPattern {
+	return &RelPattern{variable: variable, types: types}
+}
+
+// Props requires the relationship to have the properties, the values are sent as parameters.
+func (r *RelPattern) Props(props map[string]interface{}) *RelPattern {
+	r.props = props
+	return r
+}
+
+func (r *RelPattern) render(q *Query) string {
+	var b strings.Builder
+	b.WriteString("[")
+	if r.variable != "" {
+		b.WriteString(q.escape(r.variable))
+	}
+	for i, typ := range r.types {
+		if i == 0 {
+			b.WriteString(":")
+		} else {
+			b.WriteString("|")
+		}
+		b.WriteString(q.escape(typ))
+	}
+	b.WriteString(renderProps(q, r.props))
+	b.WriteString("]")
+	return b.String()
+}
+
+// PathPattern is a chain of nodes connected by relationships.
+type PathPattern struct {
+	start *NodePattern
+	steps []pathStep
+}
+
+type pathStep struct {
+	left  string
+	rel   *RelPattern
+	right string
+	node  *NodePattern
+}
+
+// Out continues the path over an outgoing relationship.
+func (p *PathPattern) Out(rel *RelPattern, to *NodePattern) *PathPattern {
+	p.steps = append(p.steps, pathStep{left: "-", rel: rel, right: "->", node: to})
+	return p
+}
+
+// In continues the path over an incoming relationship.
+func (p *PathPattern) In(rel *RelPattern, to *NodePattern) *PathPattern {
+	p.steps = append(p.steps, pathStep{left: "<-", rel: rel, right: "-", node: to})
+	return p
+}
+
+// Both continues the path over a relationship in either direction.
+func (p *PathPattern) Both(rel *RelPattern, to *NodePattern) *PathPattern {
+	p.steps = append(p.steps, pathStep{left: "-", rel: rel, right: "-", node: to})
+	return p
+}
+
+func (p *PathPattern) render(q *Query) string {
+	var b strings.Builder
+	b.WriteString(p.start.render(q))
+	for _, step := range p.steps {
+		b.WriteString(step.left)
+		b.WriteString(step.rel.render(q))
+		b.WriteString(step.right)
+		b.WriteString(step.node.render(q))
+	}
+	return b.String()
+}
+
+// Renders properties in key order to make the query predictable.
+func renderProps(q *Query, props map[string]interface{}) string {
+	if len(props) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = q.escape(key) + ": " + operand(props[key]).render(q)
+	}
+	return " {" + strings.Join(parts, ", ") + "}"
+}
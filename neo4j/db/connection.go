@@ -42,6 +42,9 @@ type Command struct {
 	Cypher    string
 	Params    map[string]interface{}
 	FetchSize int
+	// Fraction of a batch that is consumed before the rest of the batch is buffered and the
+	// next batch is requested, zero disables prefetching.
+	PrefetchRatio float64
 }
 
 type TxConfig struct {
@@ -70,7 +73,8 @@ type Connection interface {
 	// Buffers all records on the stream, records, summary and error will be received through call to Next
 	// The Connection implementation should preserve/buffer streams automatically if needed when new
 	// streams are created and the server doesn't support multiple streams. Use Buffer to force
-	// buffering before calling Reset to get all records and the bookmark.
+	// buffering before calling Reset to get all records and the bookmark. Consume after Buffer
+	// returns the summary without discarding the buffered records.
 	Buffer(streamHandle StreamHandle) error
 	// Returns bookmark from last committed transaction or last finished auto-commit transaction.
 	// Note that if there is an ongoing auto-commit transaction (stream active) the bookmark
@@ -124,3 +128,15 @@ type DatabaseSelector interface {
 	// databases without a reset inbetween.
 	SelectDatabase(database string)
 }
+
+// If the connection knows the authentication token that it was created with.
+type AuthTokenHolder interface {
+	AuthToken() map[string]interface{}
+}
+
+// If the connection measures how long it takes for the server to respond to queries.
+type ResponseTimer interface {
+	// Returns the time from sending the latest query until the server responded to it, zero
+	// when no query has been sent since the previous call.
+	ResponseTime() time.Duration
+}
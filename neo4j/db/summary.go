@@ -106,6 +106,8 @@ type Notification struct {
 	Position *InputPosition
 	// Severity contains the severity level of this notification.
 	Severity string
+	// Category contains the category of this notification, only sent by newer servers.
+	Category string
 }
 
 // InputPosition contains information about a specific position in a statement
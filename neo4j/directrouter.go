@@ -47,3 +47,11 @@ func (r *directRouter) Invalidate(database string) {
 
 func (r *directRouter) CleanUp() {
 }
+
+func (r *directRouter) KnownServers() []string {
+	return []string{r.address}
+}
+
+func (r *directRouter) KnownReadersAndWriters() []string {
+	return []string{r.address}
+}
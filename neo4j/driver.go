@@ -23,11 +23,14 @@ package neo4j
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/connector"
@@ -59,10 +62,41 @@ type Driver interface {
 	// establishing a network connection with the remote. Returns nil if succesful
 	// or error describing the problem.
 	VerifyConnectivity() error
+	// Fills the connection pool with idle connections to avoid paying the connect
+	// cost on the first requests. The routing table for the default database is
+	// read and Config.MinIdleConnectionsPerServer, but at least one, connections
+	// are opened to each known server. Returns nil if successful or error describing
+	// the last problem.
+	WarmUp() error
+	// Returns the routing table for the database, the default database is used when database
+	// is empty. A new routing table is read from the cluster if there is no known routing table
+	// or if the known routing table has expired. Only available when routing is used.
+	RoutingTable(ctx context.Context, database string) (*RoutingTable, error)
+	// Reads a new routing table for the database from the cluster regardless of the state of
+	// the known routing table. The provided bookmarks are sent to the router. Only available
+	// when routing is used.
+	ForceRoutingTableUpdate(ctx context.Context, database string, bookmarks ...string) (*RoutingTable, error)
 	// Close the driver and all underlying connections
 	Close() error
 }
 
+// RoutingTable contains the servers of a cluster that serve a database and for how long
+// that information is valid.
+type RoutingTable struct {
+	// DatabaseName is the name of the database that the routing table is valid for.
+	DatabaseName string
+	// Routers are the servers that can provide new routing tables.
+	Routers []string
+	// Readers are the servers that serve reads.
+	Readers []string
+	// Writers are the servers that serve writes.
+	Writers []string
+	// TimeToLive is the validity of the routing table as reported by the server.
+	TimeToLive time.Duration
+	// ExpiresAt is the point in time when the driver will read a new routing table.
+	ExpiresAt time.Time
+}
+
 // NewDriver is the entry point to the neo4j driver to create an instance of a Driver. It is the first function to
 // be called in order to establish a connection to a neo4j database. It requires a Bolt URI and an authentication
 // token as parameters and can also take optional configuration function(s) as variadic parameters.
@@ -74,17 +108,36 @@ type Driver interface {
 // and its host part set to be one of the core cluster members.
 //	driver, err = NewDriver("neo4j://core.db.server:7687", BasicAuth(username, password))
 //
+// When the server is only reachable through an HTTP ingress, Bolt can be carried over WebSocket by
+// adding '+ws' to the scheme: 'bolt+ws', 'bolt+ws+s', 'bolt+ws+ssc', 'neo4j+ws', 'neo4j+ws+s' or
+// 'neo4j+ws+ssc'. The path of the URI is used as the path of the WebSocket request.
+//	driver, err = NewDriver("bolt+ws+s://ingress.db.server:443/bolt", BasicAuth(username, password))
+//
 // You can override default configuration options by providing a configuration function(s)
 //	driver, err = NewDriver(uri, BasicAuth(username, password), function (config *Config) {
 // 		config.MaxConnectionPoolSize = 10
 // 	})
 func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Driver, error) {
+	return newDriver(target, newStaticAuth(auth), configurers...)
+}
+
+// NewDriverWithAuthTokenManager creates a driver in the same way as NewDriver but retrieves the
+// authentication token from the manager every time a new connection is established. This lets
+// the token change while the driver is in use, see AuthTokenManager.
+func NewDriverWithAuthTokenManager(target string, manager AuthTokenManager, configurers ...func(*Config)) (Driver, error) {
+	if manager == nil {
+		return nil, &UsageError{Message: "Auth token manager cannot be nil"}
+	}
+	return newDriver(target, newManagedAuth(manager), configurers...)
+}
+
+func newDriver(target string, auth *driverAuth, configurers ...func(*Config)) (Driver, error) {
 	parsed, err := url.Parse(target)
 	if err != nil {
 		return nil, err
 	}
 
-	d := driver{target: parsed}
+	d := driver{target: parsed, auth: auth}
 
 	routing := true
 	d.connector.Network = "tcp"
@@ -114,12 +167,35 @@ func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Dri
 	case "neo4j+ssc":
 		d.connector.SkipVerify = true
 	case "neo4j+s":
+	case "bolt+ws":
+		routing = false
+		d.connector.SkipEncryption = true
+		d.connector.WebSocket = true
+	case "bolt+ws+s":
+		routing = false
+		d.connector.WebSocket = true
+	case "bolt+ws+ssc":
+		routing = false
+		d.connector.SkipVerify = true
+		d.connector.WebSocket = true
+	case "neo4j+ws":
+		d.connector.SkipEncryption = true
+		d.connector.WebSocket = true
+	case "neo4j+ws+s":
+		d.connector.WebSocket = true
+	case "neo4j+ws+ssc":
+		d.connector.SkipVerify = true
+		d.connector.WebSocket = true
 	default:
 		return nil, &UsageError{
 			Message: fmt.Sprintf("URI scheme %s is not supported", parsed.Scheme),
 		}
 	}
 
+	if d.connector.WebSocket {
+		d.connector.WebSocketPath = parsed.Path
+	}
+
 	if parsed.Host != "" && parsed.Port() == "" {
 		address += ":7687"
 		parsed.Host = address
@@ -148,6 +224,13 @@ func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Dri
 	}
 	d.logId = log.NewId()
 
+	// Setup event listener
+	d.listener = d.config.EventListener
+	if d.listener == nil {
+		// Default to void listener
+		d.listener = &event.Void{}
+	}
+
 	routingContext, err := routingContextFromUrl(routing, parsed)
 	if err != nil {
 		return nil, err
@@ -158,12 +241,16 @@ func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Dri
 	d.connector.SocketKeepAlive = d.config.SocketKeepalive
 	d.connector.UserAgent = d.config.UserAgent
 	d.connector.RootCAs = d.config.RootCAs
+	d.connector.ClientCertificate = d.config.ClientCertificate
+	d.connector.GetClientCertificate = d.config.GetClientCertificate
+	d.connector.ConfigureTls = d.config.ConfigureTls
+	d.connector.DialContext = d.config.DialContext
 	d.connector.Log = d.log
-	d.connector.Auth = auth.tokens
 	d.connector.RoutingContext = routingContext
 
 	// Let the pool use the same logid as the driver to simplify log reading.
-	d.pool = pool.New(d.config.MaxConnectionPoolSize, d.config.MaxConnectionLifetime, d.connector.Connect, d.log, d.logId)
+	d.pool = pool.New(d.config.MaxConnectionPoolSize, d.config.MaxConnectionLifetime, d.connect, d.log, d.logId, d.listener, d.config.LoadBalancingStrategy, d.config.CircuitBreakerThreshold, d.config.CircuitBreakerOpenDuration)
+	d.auth.pool = d.pool
 
 	if !routing {
 		d.router = &directRouter{address: address}
@@ -171,17 +258,28 @@ func NewDriver(target string, auth AuthToken, configurers ...func(*Config)) (Dri
 		var routersResolver func() []string
 		addressResolverHook := d.config.AddressResolver
 		if addressResolverHook != nil {
+			hosts := &resolvedHosts{}
 			routersResolver = func() []string {
 				addresses := addressResolverHook(parsed)
 				servers := make([]string, len(addresses))
 				for i, a := range addresses {
-					servers[i] = fmt.Sprintf("%s:%s", a.Hostname(), a.Port())
+					servers[i] = net.JoinHostPort(a.Hostname(), a.Port())
+					hosts.add(servers[i], a)
 				}
 				return servers
 			}
+			d.connector.ServerName = hosts.host
 		}
 		// Let the router use the same logid as the driver to simplify log reading.
-		d.router = router.New(address, routersResolver, routingContext, d.pool, d.log, d.logId)
+		d.router = router.New(address, routersResolver, routingContext, d.pool, d.log, d.logId, d.listener, d.config.StaleRoutingTableGracePeriod, d.config.HomeDatabaseCacheTTL, d.principal)
+	}
+
+	// Maintenance handles idle connections, refreshes routing tables ahead of time and removes
+	// connections to servers that are no longer part of the cluster, only when asked for.
+	if d.config.MinIdleConnectionsPerServer > 0 || d.config.MaxConnectionIdleTime > 0 || d.config.RoutingTableRefreshAhead > 0 {
+		d.stopMaintenance = make(chan struct{})
+		d.maintenanceWg.Add(1)
+		go d.maintain(d.pool, d.router, d.stopMaintenance)
 	}
 
 	d.log.Infof(log.Driver, d.logId, "Created { target: %s }", address)
@@ -233,6 +331,10 @@ type sessionRouter interface {
 	GetNameOfDefaultDatabase(ctx context.Context, bookmarks []string, user string, boltLogger log.BoltLogger) (string, error)
 	Invalidate(database string)
 	CleanUp()
+	// Returns all servers that are known to be routers, readers or writers.
+	KnownServers() []string
+	// Returns all servers that are known to be readers or writers.
+	KnownReadersAndWriters() []string
 }
 
 type driver struct {
@@ -244,6 +346,86 @@ type driver struct {
 	router    sessionRouter
 	logId     string
 	log       log.Logger
+	listener  event.Listener
+	auth      *driverAuth
+
+	stopMaintenance chan struct{}
+	maintenanceWg   sync.WaitGroup
+}
+
+// Interval between background maintenance runs on the connection pool.
+const maintenanceInterval = 1 * time.Second
+
+// Background maintenance of the connection pool, runs until stop is closed.
+func (d *driver) maintain(pool *pool.Pool, sessRouter sessionRouter, stop chan struct{}) {
+	defer d.maintenanceWg.Done()
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if r, isRouter := sessRouter.(*router.Router); isRouter && d.config.RoutingTableRefreshAhead > 0 {
+			d.refreshRoutingTables(r, stop)
+		}
+		minIdle := d.config.MinIdleConnectionsPerServer
+		if d.config.MaxConnectionIdleTime > 0 {
+			pool.CloseIdle(d.config.MaxConnectionIdleTime, minIdle)
+		}
+		knownServers := sessRouter.KnownServers()
+		// No known servers means that no routing table has been read yet or that all of them
+		// have been cleaned up, keep the connections until there is something to compare with.
+		if len(knownServers) > 0 {
+			pool.CloseUnknown(knownServers)
+		}
+		if minIdle > 0 {
+			if err := pool.FillIdle(sessRouter.KnownReadersAndWriters(), minIdle); err != nil {
+				d.log.Warnf(log.Driver, d.logId, "Failed to keep idle connections: %s", err)
+			}
+		}
+	}
+}
+
+// Refreshes routing tables that are about to expire, aborted when the driver is closed.
+func (d *driver) refreshRoutingTables(r *router.Router, stop chan struct{}) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if d.config.ConnectionAcquisitionTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), d.config.ConnectionAcquisitionTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-done:
+		}
+	}()
+	r.RefreshExpiring(ctx, d.config.RoutingTableRefreshAhead)
+}
+
+// Returns the user that the driver authenticates as.
+func (d *driver) principal() string {
+	return d.auth.principal()
+}
+
+// Connects using the current authentication token.
+func (d *driver) connect(address string, boltLogger log.BoltLogger) (db.Connection, error) {
+	token, err := d.auth.token()
+	if err != nil {
+		return nil, err
+	}
+	connector := d.connector
+	connector.Auth = token
+	return connector.Connect(address, boltLogger)
 }
 
 func (d *driver) Target() url.URL {
@@ -264,7 +446,7 @@ func (d *driver) Session(accessMode AccessMode, bookmarks ...string) (Session, e
 		DatabaseName: db.DefaultDatabase,
 	}
 	return newSession(
-		d.config, sessConfig, d.router, d.pool, d.log), nil
+		d.config, sessConfig, d.router, d.pool, d.log, d.listener, d.auth), nil
 }
 
 func (d *driver) NewSession(config SessionConfig) Session {
@@ -278,7 +460,11 @@ func (d *driver) NewSession(config SessionConfig) Session {
 		return &sessionWithError{
 			err: &UsageError{Message: "Trying to create session on closed driver"}}
 	}
-	return newSession(d.config, config, d.router, d.pool, d.log)
+	if config.DefaultTxTimeout < 0 {
+		return &sessionWithError{
+			err: &UsageError{Message: "Default transaction timeout cannot be smaller than 0"}}
+	}
+	return newSession(d.config, config, d.router, d.pool, d.log, d.listener, d.auth)
 }
 
 func (d *driver) VerifyConnectivity() error {
@@ -292,12 +478,77 @@ func (d *driver) VerifyConnectivity() error {
 	return err
 }
 
+func (d *driver) WarmUp() error {
+	d.mut.Lock()
+	pool := d.pool
+	d.mut.Unlock()
+	if pool == nil {
+		return &UsageError{Message: "Trying to warm up closed driver"}
+	}
+
+	ctx := context.Background()
+	if d.config.ConnectionAcquisitionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.ConnectionAcquisitionTimeout)
+		defer cancel()
+	}
+	// Make sure that there is a routing table to get the servers from
+	if _, err := d.router.Readers(ctx, nil, db.DefaultDatabase, nil); err != nil {
+		return wrapError(err)
+	}
+	num := d.config.MinIdleConnectionsPerServer
+	if num < 1 {
+		num = 1
+	}
+	return wrapError(pool.FillIdle(d.router.KnownReadersAndWriters(), num))
+}
+
+func (d *driver) RoutingTable(ctx context.Context, database string) (*RoutingTable, error) {
+	return d.routingTable(ctx, database, nil, false)
+}
+
+func (d *driver) ForceRoutingTableUpdate(ctx context.Context, database string, bookmarks ...string) (*RoutingTable, error) {
+	return d.routingTable(ctx, database, bookmarks, true)
+}
+
+func (d *driver) routingTable(ctx context.Context, database string, bookmarks []string, force bool) (*RoutingTable, error) {
+	d.mut.Lock()
+	closed := d.pool == nil
+	d.mut.Unlock()
+	if closed {
+		return nil, &UsageError{Message: "Trying to get routing table on closed driver"}
+	}
+	r, isRouter := d.router.(*router.Router)
+	if !isRouter {
+		return nil, &UsageError{Message: fmt.Sprintf("Routing table is not available for URL scheme %s", d.target.Scheme)}
+	}
+	if force {
+		r.Invalidate(database)
+	}
+	table, expires, err := r.Table(ctx, cleanupBookmarks(bookmarks), database, nil)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &RoutingTable{
+		DatabaseName: table.DatabaseName,
+		Routers:      table.Routers,
+		Readers:      table.Readers,
+		Writers:      table.Writers,
+		TimeToLive:   time.Duration(table.TimeToLive) * time.Second,
+		ExpiresAt:    expires,
+	}, nil
+}
+
 func (d *driver) Close() error {
 	d.mut.Lock()
 	defer d.mut.Unlock()
 	// Safeguard against closing more than once
 	if d.pool != nil {
+		if d.stopMaintenance != nil {
+			close(d.stopMaintenance)
+		}
 		d.pool.Close()
+		d.maintenanceWg.Wait()
 	}
 	d.pool = nil
 	d.log.Infof(log.Driver, d.logId, "Closed")
@@ -20,8 +20,10 @@
 package neo4j
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/router"
 	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
@@ -83,6 +85,14 @@ func assertNetwork(t *testing.T, d Driver, network string) {
 	}
 }
 
+func assertWebSocket(t *testing.T, d Driver, webSocket bool) {
+	t.Helper()
+	c := d.(*driver).connector
+	if c.WebSocket != webSocket {
+		t.Errorf("WebSocket mismatch, %t vs %t", webSocket, c.WebSocket)
+	}
+}
+
 func TestDriverURISchemes(t *testing.T) {
 	uriSchemeTests := []struct {
 		scheme         string
@@ -92,14 +102,21 @@ func TestDriverURISchemes(t *testing.T) {
 		skipVerify     bool
 		network        string
 		address        string
+		webSocket      bool
 	}{
-		{"bolt", "bolt://localhost:7687", false, true, false, "tcp", "localhost:7687"},
-		{"bolt+s", "bolt+s://localhost:7687", false, false, false, "tcp", "localhost:7687"},
-		{"bolt+ssc", "bolt+ssc://localhost:7687", false, false, true, "tcp", "localhost:7687"},
-		{"bolt+unix", "bolt+unix:///tmp/a.socket", false, true, false, "unix", "/tmp/a.socket"},
-		{"neo4j", "neo4j://localhost:7687", true, true, false, "tcp", ""},
-		{"neo4j+s", "neo4j+s://localhost:7687", true, false, false, "tcp", ""},
-		{"neo4j+ssc", "neo4j+ssc://localhost:7687", true, false, true, "tcp", ""},
+		{"bolt", "bolt://localhost:7687", false, true, false, "tcp", "localhost:7687", false},
+		{"bolt+s", "bolt+s://localhost:7687", false, false, false, "tcp", "localhost:7687", false},
+		{"bolt+ssc", "bolt+ssc://localhost:7687", false, false, true, "tcp", "localhost:7687", false},
+		{"bolt+unix", "bolt+unix:///tmp/a.socket", false, true, false, "unix", "/tmp/a.socket", false},
+		{"neo4j", "neo4j://localhost:7687", true, true, false, "tcp", "", false},
+		{"neo4j+s", "neo4j+s://localhost:7687", true, false, false, "tcp", "", false},
+		{"neo4j+ssc", "neo4j+ssc://localhost:7687", true, false, true, "tcp", "", false},
+		{"bolt+ws", "bolt+ws://localhost:7687", false, true, false, "tcp", "localhost:7687", true},
+		{"bolt+ws+s", "bolt+ws+s://localhost:7687", false, false, false, "tcp", "localhost:7687", true},
+		{"bolt+ws+ssc", "bolt+ws+ssc://localhost:7687", false, false, true, "tcp", "localhost:7687", true},
+		{"neo4j+ws", "neo4j+ws://localhost:7687", true, true, false, "tcp", "", true},
+		{"neo4j+ws+s", "neo4j+ws+s://localhost:7687", true, false, false, "tcp", "", true},
+		{"neo4j+ws+ssc", "neo4j+ws+ssc://localhost:7687", true, false, true, "tcp", "", true},
 	}
 
 	for _, tt := range uriSchemeTests {
@@ -119,6 +136,7 @@ func TestDriverURISchemes(t *testing.T) {
 				assertSkipVerify(t, driver, tt.skipVerify)
 			}
 			assertNetwork(t, driver, tt.network)
+			assertWebSocket(t, driver, tt.webSocket)
 		})
 	}
 }
@@ -167,6 +185,14 @@ func TestDriverURIRoutingContext(t *testing.T) {
 	})
 }
 
+func TestDriverWebSocketPath(t *testing.T) {
+	d, err := NewDriver("bolt+ws+s://ingress:443/db/bolt", NoAuth())
+	AssertNoError(t, err)
+	assertNoRouterAddress(t, d, "ingress:443")
+	assertWebSocket(t, d, true)
+	AssertStringEqual(t, d.(*driver).connector.WebSocketPath, "/db/bolt")
+}
+
 func TestDriverDefaultPort(t *testing.T) {
 	t.Run("neo4j://localhost should default to port 7687", func(t1 *testing.T) {
 		driver, err := NewDriver("neo4j://localhost", NoAuth())
@@ -211,6 +237,77 @@ func TestNewDriverAndClose(t *testing.T) {
 	}
 }
 
+func TestDriverMaintenance(t *testing.T) {
+	maintenanceTests := []struct {
+		name        string
+		testing     string
+		configurer  func(*Config)
+		maintenance bool
+	}{
+		{"Direct", "bolt://localhost:7687", func(*Config) {}, false},
+		{"Routing", "neo4j://localhost:7687", func(*Config) {}, false},
+		{"Routing refresh ahead", "neo4j://localhost:7687", func(c *Config) { c.RoutingTableRefreshAhead = time.Second }, true},
+		{"Min idle", "bolt://localhost:7687", func(c *Config) { c.MinIdleConnectionsPerServer = 1 }, true},
+		{"Max idle time", "bolt://localhost:7687", func(c *Config) { c.MaxConnectionIdleTime = time.Minute }, true},
+	}
+
+	for _, tt := range maintenanceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDriver(tt.testing, NoAuth(), tt.configurer)
+			AssertNoError(t, err)
+			stop := d.(*driver).stopMaintenance
+			if (stop != nil) != tt.maintenance {
+				t.Errorf("Expected background maintenance to be %t", tt.maintenance)
+			}
+
+			err = d.Close()
+			AssertNoError(t, err)
+			if stop != nil {
+				select {
+				case <-stop:
+				default:
+					t.Errorf("should have stopped background maintenance")
+				}
+			}
+		})
+	}
+}
+
+func TestDriverWarmUp(t *testing.T) {
+	t.Run("Fails on closed driver", func(t *testing.T) {
+		driver, err := NewDriver("bolt://localhost:7687", NoAuth())
+		AssertNoError(t, err)
+		driver.Close()
+
+		err = driver.WarmUp()
+		if !IsUsageError(err) {
+			t.Errorf("should not allow warm up after driver being closed")
+		}
+	})
+}
+
+func TestDriverRoutingTable(t *testing.T) {
+	t.Run("Not available without routing", func(t *testing.T) {
+		driver, err := NewDriver("bolt://localhost:7687", NoAuth())
+		AssertNoError(t, err)
+		defer driver.Close()
+
+		_, err = driver.RoutingTable(context.Background(), "")
+		assertUsageError(t, err)
+		_, err = driver.ForceRoutingTableUpdate(context.Background(), "")
+		assertUsageError(t, err)
+	})
+
+	t.Run("Fails on closed driver", func(t *testing.T) {
+		driver, err := NewDriver("neo4j://localhost:7687", NoAuth())
+		AssertNoError(t, err)
+		driver.Close()
+
+		_, err = driver.RoutingTable(context.Background(), "")
+		assertUsageError(t, err)
+	})
+}
+
 func TestDriverSessionCreation(t *testing.T) {
 	driverSessionCreationTests := []struct {
 		name      string
@@ -242,3 +339,13 @@ func TestDriverSessionCreation(t *testing.T) {
 		})
 	}
 }
+
+func TestDriverSessionCreationWithNegativeTxTimeout(t *testing.T) {
+	driver, err := NewDriver("bolt://localhost:7687", NoAuth())
+	AssertNoError(t, err)
+	defer driver.Close()
+
+	sess := driver.NewSession(SessionConfig{DefaultTxTimeout: -time.Second})
+	_, err = sess.Run("cypher", nil)
+	assertUsageError(t, err)
+}
@@ -123,6 +123,8 @@ func (e *TokenExpiredError) Error() string {
 	return fmt.Sprintf("TokenExpiredError: %s (%s)", e.Code, e.Message)
 }
 
+const tokenExpiredCode = "Neo.ClientError.Security.TokenExpired"
+
 func wrapError(err error) error {
 	if err == nil {
 		return nil
@@ -139,7 +141,7 @@ func wrapError(err error) error {
 		return &UsageError{Message: err.Error()}
 	case *connector.TlsError, *connector.ConnectError:
 		return &ConnectivityError{inner: err}
-	case *pool.PoolTimeout, *pool.PoolFull:
+	case *pool.PoolTimeout, *pool.PoolFull, *pool.CircuitOpen:
 		return &ConnectivityError{inner: err}
 	case *router.ReadRoutingTableError:
 		return &ConnectivityError{inner: err}
@@ -148,9 +150,28 @@ func wrapError(err error) error {
 	case net.Error:
 		return &ConnectivityError{inner: err}
 	case *db.Neo4jError:
-		if e.Code == "Neo.ClientError.Security.TokenExpired" {
-			return &TokenExpiredError{Code: e.Code, Message: e.Msg}
+		if expired, isExpired := asTokenExpired(e); isExpired {
+			return expired
 		}
 	}
 	return err
 }
+
+// Returns the error as a TokenExpiredError if it tells that the auth token has expired, both
+// errors received from the server and errors already wrapped by wrapError are recognized.
+func asTokenExpired(err error) (*TokenExpiredError, bool) {
+	var expired *TokenExpiredError
+	if errors.As(err, &expired) {
+		return expired, true
+	}
+	var dbErr *db.Neo4jError
+	if errors.As(err, &dbErr) && dbErr.Code == tokenExpiredCode {
+		return &TokenExpiredError{Code: dbErr.Code, Message: dbErr.Msg}, true
+	}
+	return nil, false
+}
+
+func isTokenExpired(err error) bool {
+	_, isExpired := asTokenExpired(err)
+	return isExpired
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package event defines the driver lifecycle events that can be observed by
+// registering a Listener on the driver configuration.
+package event
+
+// Listener is notified about events that occur inside of the driver, for example
+// when connections are opened and closed or when routing tables are updated.
+//
+// OnEvent is called synchronously from the driver component that produced the
+// event, possibly while internal locks are held. Implementations should return
+// quickly and must not call back into the driver.
+type Listener interface {
+	OnEvent(e Event)
+}
+
+// ListenerFunc adapts a plain function to the Listener interface.
+type ListenerFunc func(e Event)
+
+func (f ListenerFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// Event is implemented by all event types sent to a Listener. Use a type switch
+// on the concrete types in this package to handle specific events.
+type Event interface {
+	event()
+}
+
+// ConnectionOpened is sent when the connection pool has established a new
+// connection to a server.
+type ConnectionOpened struct {
+	Server string
+}
+
+// Reasons for closing a connection.
+const (
+	ReasonDead    = "dead"
+	ReasonExpired = "expired"
+	ReasonIdle    = "idle"
+	ReasonUnknown = "unknown"
+	ReasonClosed  = "closed"
+)
+
+// ConnectionClosed is sent when the connection pool closes a connection to a
+// server. Reason is one of the Reason constants: the connection was dead, had
+// reached its maximum lifetime, had been idle for too long, belonged to a server
+// that is no longer part of any routing table or the pool was closed.
+type ConnectionClosed struct {
+	Server string
+	Reason string
+}
+
+// ServerPenalised is sent when the connection pool failed to connect to a server.
+// The server will be deprioritized when selecting among servers for a while.
+type ServerPenalised struct {
+	Server string
+	Err    error
+}
+
+// RoutingTableUpdated is sent when the router has read a new routing table for
+// a database. WritersChanged is true if the set of writers differs from the
+// previously known routing table for the same database or if there was no
+// previously known routing table, a change typically indicates that a new leader
+// has been elected.
+type RoutingTableUpdated struct {
+	Database        string
+	Routers         []string
+	Readers         []string
+	Writers         []string
+	PreviousWriters []string
+	WritersChanged  bool
+}
+
+// RoutingTableInvalidated is sent when the routing table for a database has been
+// invalidated, for example due to a cluster error while executing a transaction.
+// The next access will read a fresh routing table.
+type RoutingTableInvalidated struct {
+	Database string
+}
+
+// TokenExpired is sent when the server reports that the authentication token
+// used by the driver has expired.
+type TokenExpired struct {
+	Code    string
+	Message string
+}
+
+// States of a circuit breaker.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerChanged is sent when the circuit breaker for a server changes
+// state. State is one of the Circuit constants: the breaker opens after too many
+// consecutive failures, no connections are made to the server while it is open.
+// After a while it becomes half-open and lets a single probe through, the
+// breaker closes if the probe succeeds and opens again if it fails.
+type CircuitBreakerChanged struct {
+	Server string
+	State  string
+}
+
+func (ConnectionOpened) event()        {}
+func (ConnectionClosed) event()        {}
+func (ServerPenalised) event()         {}
+func (RoutingTableUpdated) event()     {}
+func (RoutingTableInvalidated) event() {}
+func (TokenExpired) event()            {}
+func (CircuitBreakerChanged) event()   {}
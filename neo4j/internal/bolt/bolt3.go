@@ -77,10 +77,12 @@ type bolt3 struct {
 	connId        string
 	logId         string
 	serverVersion string
-	tfirst        int64        // Time that server started streaming
-	pendingTx     *internalTx3 // Stashed away when tx started explcitly
-	bookmark      string       // Last bookmark
+	tfirst        int64         // Time that server started streaming
+	responseTime  time.Duration // Time until server responded to the latest query
+	pendingTx     *internalTx3  // Stashed away when tx started explcitly
+	bookmark      string        // Last bookmark
 	birthDate     time.Time
+	authToken     map[string]interface{} // Token that the connection was authenticated with
 	log           log.Logger
 	err           error // Last fatal error
 	minor         int
@@ -177,6 +179,7 @@ func (b *bolt3) connect(minor int, auth map[string]interface{}, userAgent string
 		"user_agent": userAgent,
 	}
 	// Merge authentication info into hello message
+	b.authToken = auth
 	for k, v := range auth {
 		_, exists := hello[k]
 		if exists {
@@ -440,6 +443,7 @@ func (b *bolt3) run(cypher string, params map[string]interface{}, tx *internalTx
 
 	// Append pull all message and send it along with other pending messages
 	b.out.appendPullAll()
+	sent := time.Now()
 	if b.out.send(b.conn); b.err != nil {
 		return nil, b.err
 	}
@@ -458,6 +462,7 @@ func (b *bolt3) run(cypher string, params map[string]interface{}, tx *internalTx
 	if b.err != nil {
 		return nil, b.err
 	}
+	b.responseTime = time.Since(sent)
 	b.tfirst = succ.tfirst
 	// Change state to streaming
 	if b.state == bolt3_ready {
@@ -655,6 +660,16 @@ func (b *bolt3) Birthdate() time.Time {
 	return b.birthDate
 }
 
+func (b *bolt3) AuthToken() map[string]interface{} {
+	return b.authToken
+}
+
+func (b *bolt3) ResponseTime() time.Duration {
+	t := b.responseTime
+	b.responseTime = 0
+	return t
+}
+
 func (b *bolt3) Reset() {
 	defer func() {
 		// Reset internal state
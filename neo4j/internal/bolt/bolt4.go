@@ -88,11 +88,13 @@ type bolt4 struct {
 	connId        string
 	logId         string
 	serverVersion string
-	tfirst        int64       // Time that server started streaming
-	pendingTx     internalTx4 // Stashed away when tx started explicitly
+	tfirst        int64         // Time that server started streaming
+	responseTime  time.Duration // Time until server responded to the latest query
+	pendingTx     internalTx4   // Stashed away when tx started explicitly
 	hasPendingTx  bool
 	bookmark      string // Last bookmark
 	birthDate     time.Time
+	authToken     map[string]interface{} // Token that the connection was authenticated with
 	log           log.Logger
 	databaseName  string
 	err           error // Last fatal error
@@ -238,6 +240,7 @@ func (b *bolt4) connect(minor int, auth map[string]interface{}, userAgent string
 		hello["patch_bolt"] = []string{"utc"}
 	}
 	// Merge authentication keys into hello, avoid overwriting existing keys
+	b.authToken = auth
 	for k, v := range auth {
 		_, exists := hello[k]
 		if !exists {
@@ -495,6 +498,9 @@ func (b *bolt4) discardAllStreams() {
 // Sends a PULL n request to server. State should be streaming and there should be a current stream.
 func (b *bolt4) sendPullN() {
 	b.assertState(bolt4_streaming, bolt4_streamingtx)
+	if b.streams.curr != nil {
+		b.streams.curr.batchRead = 0
+	}
 	if b.state == bolt4_streaming {
 		b.out.appendPullN(b.streams.curr.fetchSize)
 		b.out.send(b.conn)
@@ -553,6 +559,37 @@ func (b *bolt4) pauseStream() {
 	}
 }
 
+// Reads the rest of the current batch into the buffer of the stream and requests the next
+// batch right away. The round trip of the request then overlaps with the processing of the
+// buffered records. Assumes that the stream is current.
+func (b *bolt4) prefetchStream(stream *stream) {
+	for {
+		rec, batch, _ := b.receiveNext()
+		if rec != nil {
+			stream.push(rec)
+		} else if batch {
+			b.sendPullN()
+			return
+		} else {
+			// Either summary or an error, both are kept by the stream
+			return
+		}
+	}
+}
+
+// Returns the number of records of a batch after which the next batch is prefetched or zero
+// when not prefetching.
+func prefetchAfter(fetchSize int, ratio float64) int {
+	if fetchSize <= 0 || ratio <= 0 || ratio >= 1 {
+		return 0
+	}
+	n := int(float64(fetchSize) * ratio)
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
 func (b *bolt4) resumeStream(s *stream) {
 	b.streams.resume(s)
 	b.sendPullN()
@@ -561,7 +598,7 @@ func (b *bolt4) resumeStream(s *stream) {
 	}
 }
 
-func (b *bolt4) run(cypher string, params map[string]interface{}, fetchSize int, tx *internalTx4) (*stream, error) {
+func (b *bolt4) run(cypher string, params map[string]interface{}, fetchSize int, prefetchRatio float64, tx *internalTx4) (*stream, error) {
 	// If already streaming, consume the whole thing first
 	if b.state == bolt4_streaming {
 		if b.bufferStream(); b.err != nil {
@@ -600,6 +637,7 @@ func (b *bolt4) run(cypher string, params map[string]interface{}, fetchSize int,
 	}
 	// Append pull message and send it along with other pending messages
 	b.out.appendPullN(fetchSize)
+	sent := time.Now()
 	b.out.send(b.conn)
 
 	// Process server responses
@@ -618,6 +656,7 @@ func (b *bolt4) run(cypher string, params map[string]interface{}, fetchSize int,
 		// pull message as well, this will be cleaned up by Reset
 		return nil, b.err
 	}
+	b.responseTime = time.Since(sent)
 	// Extract the RUN response from success response
 	b.tfirst = succ.tfirst
 	// Change state to streaming
@@ -628,7 +667,12 @@ func (b *bolt4) run(cypher string, params map[string]interface{}, fetchSize int,
 	}
 
 	// Create a stream representation, set it to current and track it
-	stream := &stream{keys: succ.fields, qid: succ.qid, fetchSize: fetchSize}
+	stream := &stream{
+		keys:          succ.fields,
+		qid:           succ.qid,
+		fetchSize:     fetchSize,
+		prefetchAfter: prefetchAfter(fetchSize, prefetchRatio),
+	}
 	b.streams.attach(stream)
 	// No need to check streams state, we know we are streaming
 
@@ -652,7 +696,7 @@ func (b *bolt4) Run(cmd db.Command, txConfig db.TxConfig) (db.StreamHandle, erro
 		databaseName:     b.databaseName,
 		impersonatedUser: txConfig.ImpersonatedUser,
 	}
-	stream, err := b.run(cmd.Cypher, cmd.Params, cmd.FetchSize, &tx)
+	stream, err := b.run(cmd.Cypher, cmd.Params, cmd.FetchSize, cmd.PrefetchRatio, &tx)
 	if err != nil {
 		return nil, err
 	}
@@ -668,7 +712,7 @@ func (b *bolt4) RunTx(txh db.TxHandle, cmd db.Command) (db.StreamHandle, error)
 	if !b.hasPendingTx {
 		tx = nil
 	}
-	stream, err := b.run(cmd.Cypher, cmd.Params, cmd.FetchSize, tx)
+	stream, err := b.run(cmd.Cypher, cmd.Params, cmd.FetchSize, cmd.PrefetchRatio, tx)
 	b.hasPendingTx = false
 	if err != nil {
 		return nil, err
@@ -727,6 +771,15 @@ func (b *bolt4) Next(streamHandle db.StreamHandle) (*db.Record, *db.Summary, err
 		}
 		rec, _, sum = b.receiveNext()
 	}
+	if rec != nil && stream.prefetchAfter > 0 {
+		stream.batchRead++
+		if stream.batchRead == stream.prefetchAfter {
+			// Errors while prefetching are kept by the stream and returned after the
+			// buffered records.
+			b.prefetchStream(stream)
+			return rec, nil, nil
+		}
+	}
 	return rec, sum, b.err
 }
 
@@ -862,6 +915,16 @@ func (b *bolt4) Birthdate() time.Time {
 	return b.birthDate
 }
 
+func (b *bolt4) AuthToken() map[string]interface{} {
+	return b.authToken
+}
+
+func (b *bolt4) ResponseTime() time.Duration {
+	t := b.responseTime
+	b.responseTime = 0
+	return t
+}
+
 func (b *bolt4) Reset() {
 	defer func() {
 		// Reset internal state
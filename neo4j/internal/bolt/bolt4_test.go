@@ -139,6 +139,7 @@ func TestBolt4(ot *testing.T) {
 		AssertTrue(t, bolt.IsAlive())
 		AssertTrue(t, reflect.DeepEqual(bolt.in.connReadTimeout, time.Duration(-1)))
 		AssertFalse(t, bolt.out.useUtc)
+		AssertTrue(t, reflect.DeepEqual(bolt.AuthToken(), auth))
 	})
 
 	ot.Run("Connect success with timeout hint", func(t *testing.T) {
@@ -304,6 +305,27 @@ func TestBolt4(ot *testing.T) {
 		assertBoltState(t, bolt4_ready, bolt)
 	})
 
+	ot.Run("Run measures response time", func(t *testing.T) {
+		bolt, cleanup := connectToServer(t, func(srv *bolt4server) {
+			srv.accept(4)
+			srv.waitForRun(nil)
+			srv.waitForPullN(bolt4_fetchsize)
+			time.Sleep(10 * time.Millisecond)
+			for _, x := range runResponse {
+				srv.send(x.tag, x.fields...)
+			}
+		})
+		defer cleanup()
+		defer bolt.Close()
+
+		AssertTrue(t, bolt.ResponseTime() == 0)
+		str, _ := bolt.Run(db.Command{Cypher: "cypher"}, db.TxConfig{Mode: db.ReadMode})
+		assertRunResponseOk(t, bolt, str)
+		AssertTrue(t, bolt.ResponseTime() >= 10*time.Millisecond)
+		// Only reported once
+		AssertTrue(t, bolt.ResponseTime() == 0)
+	})
+
 	ot.Run("Run auto-commit with impersonation", func(t *testing.T) {
 		cypherText := "MATCH (n)"
 		impersonatedUser := "a user"
@@ -357,6 +379,44 @@ func TestBolt4(ot *testing.T) {
 		assertBoltState(t, bolt4_ready, bolt)
 	})
 
+	ot.Run("Run auto-commit with fetch size 2 of 3 and prefetch", func(t *testing.T) {
+		pulled := make(chan bool)
+		bolt, cleanup := connectToServer(t, func(srv *bolt4server) {
+			srv.accept(4)
+			srv.waitForRun(nil)
+			srv.waitForPullN(2)
+			srv.send(runResponse[0].tag, runResponse[0].fields...)
+			srv.send(runResponse[1].tag, runResponse[1].fields...)
+			srv.send(runResponse[2].tag, runResponse[2].fields...)
+			srv.send(msgSuccess, map[string]interface{}{"has_more": true})
+			srv.waitForPullN(2)
+			close(pulled)
+			srv.send(runResponse[3].tag, runResponse[3].fields...)
+			srv.send(runResponse[4].tag, runResponse[4].fields...)
+		})
+		defer cleanup()
+		defer bolt.Close()
+
+		str, _ := bolt.Run(db.Command{Cypher: "cypher", FetchSize: 2, PrefetchRatio: 0.5}, db.TxConfig{Mode: db.ReadMode})
+		assertBoltState(t, bolt4_streaming, bolt)
+
+		// The next batch should be requested when half of the current batch has been read
+		rec, sum, err := bolt.Next(str)
+		AssertNextOnlyRecord(t, rec, sum, err)
+		select {
+		case <-pulled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Next batch was not requested")
+		}
+		for i := 2; i < len(runResponse)-1; i++ {
+			rec, sum, err = bolt.Next(str)
+			AssertNextOnlyRecord(t, rec, sum, err)
+		}
+		rec, sum, err = bolt.Next(str)
+		AssertNextOnlySummary(t, rec, sum, err)
+		assertBoltState(t, bolt4_ready, bolt)
+	})
+
 	ot.Run("Run transactional commit", func(t *testing.T) {
 		committedBookmark := "cbm"
 		bolt, cleanup := connectToServer(t, func(srv *bolt4server) {
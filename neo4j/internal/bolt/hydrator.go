@@ -876,6 +876,7 @@ func parseNotification(m map[string]interface{}) db.Notification {
 	n.Description = m["description"].(string)
 	n.Severity, _ = m["severity"].(string)
 	n.Title, _ = m["title"].(string)
+	n.Category, _ = m["category"].(string)
 	posx, exists := m["position"].(map[string]interface{})
 	if exists {
 		pos := &db.InputPosition{}
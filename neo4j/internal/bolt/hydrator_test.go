@@ -291,7 +291,7 @@ func TestHydrator(outer *testing.T) {
 				packer.Int(2)
 				packer.String("column")
 				packer.Int(3)
-				packer.MapHeader(4) // Notification map
+				packer.MapHeader(5) // Notification map
 				packer.String("code")
 				packer.String("c2")
 				packer.String("title")
@@ -300,11 +300,13 @@ func TestHydrator(outer *testing.T) {
 				packer.String("d2")
 				packer.String("severity")
 				packer.String("s2")
+				packer.String("category")
+				packer.String("cat2")
 			},
 			x: &success{bookmark: "bm", db: "sys", qid: -1, num: 4,
 				notifications: []db.Notification{
 					{Code: "c1", Title: "t1", Description: "d1", Severity: "s1", Position: &db.InputPosition{Offset: 1, Line: 2, Column: 3}},
-					{Code: "c2", Title: "t2", Description: "d2", Severity: "s2"},
+					{Code: "c2", Title: "t2", Description: "d2", Severity: "s2", Category: "cat2"},
 				}},
 		},
 		{
@@ -34,6 +34,11 @@ type stream struct {
 	qid       int64
 	fetchSize int
 	key       int64
+	// Number of records of a batch after which the next batch is prefetched, zero when not
+	// prefetching.
+	prefetchAfter int
+	// Number of records of the current batch received by Next
+	batchRead int
 }
 
 // Acts on buffered data, first return value indicates if buffering
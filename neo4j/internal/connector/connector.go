@@ -21,15 +21,18 @@
 package connector
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/bolt"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/websocket"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
 
@@ -44,6 +47,23 @@ type Connector struct {
 	UserAgent       string
 	RoutingContext  map[string]string
 	Network         string
+	// Presented to servers that request a client certificate, GetClientCertificate takes
+	// precedence and is called once for every TLS handshake.
+	ClientCertificate    *tls.Certificate
+	GetClientCertificate func() (*tls.Certificate, error)
+	// Called before every TLS handshake with the configuration built from the fields above,
+	// returns the configuration to use.
+	ConfigureTls func(address string, config *tls.Config) (*tls.Config, error)
+	// Replaces the default dialer, DialTimeout is applied through the context but
+	// SocketKeepAlive is up to the custom dialer.
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+	// Returns the name that the certificate of the server is verified against, the host of the
+	// address is used when not set or when an empty name is returned. Needed when the host name
+	// has been resolved to an IP address before connecting.
+	ServerName func(address string) string
+	// Carries Bolt in WebSocket messages, on top of TLS unless SkipEncryption is set.
+	WebSocket     bool
+	WebSocketPath string
 }
 
 type ConnectError struct {
@@ -54,6 +74,10 @@ func (e *ConnectError) Error() string {
 	return e.inner.Error()
 }
 
+func (e *ConnectError) Unwrap() error {
+	return e.inner
+}
+
 type TlsError struct {
 	inner error
 }
@@ -62,20 +86,30 @@ func (e *TlsError) Error() string {
 	return e.inner.Error()
 }
 
+func (e *TlsError) Unwrap() error {
+	return e.inner
+}
+
 func (c Connector) Connect(address string, boltLogger log.BoltLogger) (db.Connection, error) {
 	dialer := net.Dialer{Timeout: c.DialTimeout}
 	if !c.SocketKeepAlive {
 		dialer.KeepAlive = -1 * time.Second // Turns keep-alive off
 	}
 
-	conn, err := dialer.Dial(c.Network, address)
+	var conn net.Conn
+	var err error
+	if c.DialContext != nil {
+		conn, err = c.dial(address)
+	} else {
+		conn, err = dialer.Dial(c.Network, address)
+	}
 	if err != nil {
 		return nil, &ConnectError{inner: err}
 	}
 
 	// TLS not requested, perform Bolt handshake
 	if c.SkipEncryption {
-		return bolt.Connect(address, conn, c.Auth, c.UserAgent, c.RoutingContext, c.Log, boltLogger)
+		return c.boltConnect(address, conn, boltLogger)
 	}
 
 	// TLS requested, continue with handshake
@@ -84,17 +118,118 @@ func (c Connector) Connect(address string, boltLogger log.BoltLogger) (db.Connec
 		conn.Close()
 		return nil, err
 	}
-	config := tls.Config{InsecureSkipVerify: c.SkipVerify, RootCAs: c.RootCAs, ServerName: serverName}
-	tlsconn := tls.Client(conn, &config)
+	if c.ServerName != nil {
+		if name := c.ServerName(address); name != "" {
+			serverName = name
+		}
+	}
+	config := &tls.Config{InsecureSkipVerify: c.SkipVerify, RootCAs: c.RootCAs, ServerName: serverName}
+	certRequested := false
+	if c.ClientCertificate != nil || c.GetClientCertificate != nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certRequested = true
+			return c.clientCertificate()
+		}
+	}
+	if c.ConfigureTls != nil {
+		config, err = c.configureTls(address, config)
+		if err != nil {
+			conn.Close()
+			return nil, &TlsError{inner: err}
+		}
+	}
+	tlsconn := tls.Client(conn, config)
 	err = tlsconn.Handshake()
 	if err != nil {
 		if err == io.EOF {
 			// Give a bit nicer error message
 			err = errors.New("Remote end closed the connection, check that TLS is enabled on the server")
+		} else if certRequested && isAlert(err) {
+			err = fmt.Errorf("Server rejected the client certificate: %w", err)
 		}
 		conn.Close()
 		return nil, &TlsError{inner: err}
 	}
 	// Perform Bolt handshake
-	return bolt.Connect(address, tlsconn, c.Auth, c.UserAgent, c.RoutingContext, c.Log, boltLogger)
+	boltConn, err := c.boltConnect(address, tlsconn, boltLogger)
+	if err != nil && certRequested && isAlert(err) {
+		// With TLS 1.3 the server verifies the client certificate after the client considers
+		// the handshake to be completed, the rejection is received on the first read.
+		conn.Close()
+		return nil, &TlsError{inner: fmt.Errorf("Server rejected the client certificate: %w", err)}
+	}
+	return boltConn, err
+}
+
+// Performs the Bolt handshake, within WebSocket messages when requested.
+func (c Connector) boltConnect(address string, conn net.Conn, boltLogger log.BoltLogger) (db.Connection, error) {
+	if c.WebSocket {
+		wsConn, err := websocket.Client(conn, address, c.WebSocketPath)
+		if err != nil {
+			conn.Close()
+			return nil, &ConnectError{inner: err}
+		}
+		conn = wsConn
+	}
+	return bolt.Connect(address, conn, c.Auth, c.UserAgent, c.RoutingContext, c.Log, boltLogger)
+}
+
+func (c Connector) dial(address string) (net.Conn, error) {
+	ctx := context.Background()
+	if c.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.DialTimeout)
+		defer cancel()
+	}
+	conn, err := c.DialContext(ctx, c.Network, address)
+	if err == nil && conn == nil {
+		err = errors.New("Dialer returned no connection")
+	}
+	return conn, err
+}
+
+// Lets the hook modify or replace the TLS configuration, the scheme still decides whether
+// the server certificate is verified.
+func (c Connector) configureTls(address string, config *tls.Config) (*tls.Config, error) {
+	configured, err := c.ConfigureTls(address, config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to configure TLS for %s: %w", address, err)
+	}
+	if configured == nil {
+		return nil, fmt.Errorf("Failed to configure TLS for %s: no configuration returned", address)
+	}
+	if configured != config {
+		// Don't modify a configuration that might be shared
+		configured = configured.Clone()
+	}
+	configured.InsecureSkipVerify = c.SkipVerify
+	if configured.ServerName == "" {
+		configured.ServerName = config.ServerName
+	}
+	return configured, nil
+}
+
+// Returns the certificate to present to the server, it is always presented even if it isn't
+// issued by any of the authorities that the server accepts, the server error is clearer than
+// silently leaving it out.
+func (c Connector) clientCertificate() (*tls.Certificate, error) {
+	if c.GetClientCertificate == nil {
+		return c.ClientCertificate, nil
+	}
+	cert, err := c.GetClientCertificate()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get client certificate: %w", err)
+	}
+	if cert == nil {
+		// Continue without certificate and let the server decide
+		return &tls.Certificate{}, nil
+	}
+	return cert, nil
+}
+
+// Checks if the error is a TLS alert sent by the server, like 'bad certificate' or
+// 'certificate required'.
+func isAlert(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "remote error"
 }
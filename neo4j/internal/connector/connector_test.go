@@ -0,0 +1,572 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connector
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+)
+
+type certAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newCertAuthority(t *testing.T, name string) *certAuthority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &certAuthority{cert: cert, key: key, pool: pool}
+}
+
+func (ca *certAuthority) issue(t *testing.T, name string, usage x509.ExtKeyUsage) *tls.Certificate {
+	t.Helper()
+	return ca.issueFor(t, name, usage, net.ParseIP("127.0.0.1"))
+}
+
+// Issues a certificate that is only valid for the name and the IP addresses.
+func (ca *certAuthority) issueFor(t *testing.T, name string, usage x509.ExtKeyUsage, ips ...net.IP) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  ips,
+		DNSNames:     []string{name},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// Accepts TLS connections and reports the state of established connections. When clientCAs
+// is set, clients must present a certificate issued by one of them. Responds to the Bolt
+// handshake with a version that isn't supported to end the connection attempt once TLS has
+// been established.
+func startTlsServer(t *testing.T, serverCert *tls.Certificate, clientCAs *x509.CertPool, maxVersion uint16) (string, chan tls.ConnectionState) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	config := &tls.Config{
+		Certificates: []tls.Certificate{*serverCert},
+		ClientCAs:    clientCAs,
+		MaxVersion:   maxVersion,
+	}
+	if clientCAs != nil {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	clients := make(chan tls.ConnectionState, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tlsConn := tls.Server(conn, config)
+				if err := tlsConn.Handshake(); err != nil {
+					return
+				}
+				clients <- tlsConn.ConnectionState()
+				handshake := make([]byte, 20)
+				if _, err := io.ReadFull(tlsConn, handshake); err != nil {
+					return
+				}
+				tlsConn.Write([]byte{0, 0, 0, 0})
+			}()
+		}
+	}()
+	return listener.Addr().String(), clients
+}
+
+func clientName(state tls.ConnectionState) string {
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+func assertUnsupportedVersion(t *testing.T, err error) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), "Server did not accept any of the requested Bolt versions") {
+		t.Errorf("Expected TLS to be established but was %v", err)
+	}
+}
+
+func TestConnectWithClientCertificate(ot *testing.T) {
+	serverCA := newCertAuthority(ot, "server ca")
+	clientCA := newCertAuthority(ot, "client ca")
+	otherCA := newCertAuthority(ot, "other ca")
+	serverCert := serverCA.issue(ot, "server", x509.ExtKeyUsageServerAuth)
+
+	newConnector := func() Connector {
+		return Connector{Network: "tcp", RootCAs: serverCA.pool, DialTimeout: time.Second, Log: log.Void{}}
+	}
+
+	for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+		name := "TLS 1.2"
+		if version == tls.VersionTLS13 {
+			name = "TLS 1.3"
+		}
+		ot.Run(name, func(vt *testing.T) {
+			vt.Run("Presents static certificate", func(t *testing.T) {
+				address, clients := startTlsServer(t, serverCert, clientCA.pool, version)
+				c := newConnector()
+				c.ClientCertificate = clientCA.issue(t, "client", x509.ExtKeyUsageClientAuth)
+
+				_, err := c.Connect(address, nil)
+				assertUnsupportedVersion(t, err)
+				if client := clientName(<-clients); client != "client" {
+					t.Errorf("Expected server to see client certificate but was %s", client)
+				}
+			})
+
+			vt.Run("Calls callback for every connection", func(t *testing.T) {
+				address, clients := startTlsServer(t, serverCert, clientCA.pool, version)
+				certs := []*tls.Certificate{
+					clientCA.issue(t, "first", x509.ExtKeyUsageClientAuth),
+					clientCA.issue(t, "second", x509.ExtKeyUsageClientAuth),
+				}
+				c := newConnector()
+				c.GetClientCertificate = func() (*tls.Certificate, error) {
+					cert := certs[0]
+					certs = certs[1:]
+					return cert, nil
+				}
+
+				for _, expected := range []string{"first", "second"} {
+					_, err := c.Connect(address, nil)
+					assertUnsupportedVersion(t, err)
+					if client := clientName(<-clients); client != expected {
+						t.Errorf("Expected server to see %s but was %s", expected, client)
+					}
+				}
+			})
+
+			vt.Run("Rejected certificate", func(t *testing.T) {
+				address, _ := startTlsServer(t, serverCert, clientCA.pool, version)
+				c := newConnector()
+				c.ClientCertificate = otherCA.issue(t, "client", x509.ExtKeyUsageClientAuth)
+
+				_, err := c.Connect(address, nil)
+				var tlsErr *TlsError
+				if !errors.As(err, &tlsErr) {
+					t.Fatalf("Expected TLS error but was %T: %v", err, err)
+				}
+				if !strings.Contains(err.Error(), "rejected the client certificate") {
+					t.Errorf("Expected error to tell that the certificate was rejected but was %v", err)
+				}
+			})
+		})
+	}
+
+	ot.Run("Callback error", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, clientCA.pool, 0)
+		c := newConnector()
+		cause := errors.New("no certificate")
+		c.GetClientCertificate = func() (*tls.Certificate, error) { return nil, cause }
+
+		_, err := c.Connect(address, nil)
+		var tlsErr *TlsError
+		if !errors.As(err, &tlsErr) || !errors.Is(err, cause) {
+			t.Errorf("Expected TLS error caused by callback but was %T: %v", err, err)
+		}
+	})
+}
+
+func assertTlsError(t *testing.T, err error) {
+	t.Helper()
+	var tlsErr *TlsError
+	if !errors.As(err, &tlsErr) {
+		t.Errorf("Expected TLS error but was %T: %v", err, err)
+	}
+}
+
+func TestConnectConfigureTls(ot *testing.T) {
+	serverCA := newCertAuthority(ot, "server ca")
+	serverCert := serverCA.issue(ot, "server", x509.ExtKeyUsageServerAuth)
+
+	newConnector := func() Connector {
+		return Connector{Network: "tcp", RootCAs: serverCA.pool, DialTimeout: time.Second, Log: log.Void{}}
+	}
+
+	ot.Run("Modifies configuration", func(t *testing.T) {
+		address, states := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		c.ConfigureTls = func(a string, config *tls.Config) (*tls.Config, error) {
+			if a != address {
+				t.Errorf("Expected hook to be called with %s but was %s", address, a)
+			}
+			if config.RootCAs != serverCA.pool || config.ServerName != "127.0.0.1" {
+				t.Errorf("Expected configuration built by driver but was %+v", config)
+			}
+			config.ServerName = "server"
+			config.MinVersion = tls.VersionTLS13
+			return config, nil
+		}
+
+		_, err := c.Connect(address, nil)
+		assertUnsupportedVersion(t, err)
+		state := <-states
+		if state.ServerName != "server" || state.Version != tls.VersionTLS13 {
+			t.Errorf("Expected overridden server name and version but was %s and %x", state.ServerName, state.Version)
+		}
+	})
+
+	ot.Run("Replaces configuration", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, nil, tls.VersionTLS12)
+		c := newConnector()
+		shared := &tls.Config{RootCAs: serverCA.pool, MinVersion: tls.VersionTLS13}
+		c.ConfigureTls = func(string, *tls.Config) (*tls.Config, error) {
+			return shared, nil
+		}
+
+		_, err := c.Connect(address, nil)
+		assertTlsError(t, err)
+		if shared.ServerName != "" {
+			t.Errorf("Returned configuration should not be modified")
+		}
+	})
+
+	ot.Run("Pins certificate", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		c.ConfigureTls = func(_ string, config *tls.Config) (*tls.Config, error) {
+			config.VerifyPeerCertificate = func([][]byte, [][]*x509.Certificate) error {
+				return errors.New("unknown certificate")
+			}
+			return config, nil
+		}
+
+		_, err := c.Connect(address, nil)
+		assertTlsError(t, err)
+	})
+
+	ot.Run("Scheme decides verification", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		c.RootCAs = nil
+		c.ConfigureTls = func(_ string, config *tls.Config) (*tls.Config, error) {
+			config.InsecureSkipVerify = true
+			return config, nil
+		}
+
+		_, err := c.Connect(address, nil)
+		assertTlsError(t, err)
+
+		c.SkipVerify = true
+		c.ConfigureTls = func(string, *tls.Config) (*tls.Config, error) {
+			return &tls.Config{}, nil
+		}
+		_, err = c.Connect(address, nil)
+		assertUnsupportedVersion(t, err)
+	})
+
+	ot.Run("Hook error", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		cause := errors.New("no configuration")
+		c.ConfigureTls = func(string, *tls.Config) (*tls.Config, error) {
+			return nil, cause
+		}
+
+		_, err := c.Connect(address, nil)
+		assertTlsError(t, err)
+		if !errors.Is(err, cause) {
+			t.Errorf("Expected error caused by hook but was %v", err)
+		}
+	})
+}
+
+func TestConnectServerName(ot *testing.T) {
+	serverCA := newCertAuthority(ot, "server ca")
+	// Only valid for the host name, not for the IP address that is dialled
+	serverCert := serverCA.issueFor(ot, "server.example", x509.ExtKeyUsageServerAuth)
+
+	newConnector := func() Connector {
+		return Connector{Network: "tcp", RootCAs: serverCA.pool, DialTimeout: time.Second, Log: log.Void{}}
+	}
+
+	ot.Run("Verifies resolved host name", func(t *testing.T) {
+		address, states := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		c.ServerName = func(a string) string {
+			if a != address {
+				t.Errorf("Expected server name of %s but was asked for %s", address, a)
+			}
+			return "server.example"
+		}
+
+		_, err := c.Connect(address, nil)
+		assertUnsupportedVersion(t, err)
+		if state := <-states; state.ServerName != "server.example" {
+			t.Errorf("Expected host name to be sent as server name but was %s", state.ServerName)
+		}
+	})
+
+	ot.Run("Verifies address when not resolved", func(t *testing.T) {
+		address, _ := startTlsServer(t, serverCert, nil, 0)
+		c := newConnector()
+		c.ServerName = func(string) string { return "" }
+
+		_, err := c.Connect(address, nil)
+		assertTlsError(t, err)
+	})
+}
+
+func TestConnectDialContext(ot *testing.T) {
+	serverCA := newCertAuthority(ot, "server ca")
+	serverCert := serverCA.issue(ot, "server", x509.ExtKeyUsageServerAuth)
+
+	// Serves the Bolt handshake on the server end of an in-memory connection, with TLS when
+	// config is set.
+	pipeDialer := func(t *testing.T, config *tls.Config) func(ctx context.Context, network, address string) (net.Conn, error) {
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			if network != "tcp" || address != "neo4j.internal:7687" {
+				t.Errorf("Unexpected network %s and address %s", network, address)
+			}
+			if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+				t.Errorf("Expected context to expire")
+			}
+			client, server := net.Pipe()
+			go func() {
+				defer server.Close()
+				var conn net.Conn = server
+				if config != nil {
+					conn = tls.Server(server, config)
+				}
+				handshake := make([]byte, 20)
+				if _, err := io.ReadFull(conn, handshake); err != nil {
+					return
+				}
+				conn.Write([]byte{0, 0, 0, 0})
+			}()
+			return client, nil
+		}
+	}
+
+	newConnector := func() Connector {
+		return Connector{Network: "tcp", RootCAs: serverCA.pool, DialTimeout: time.Second, Log: log.Void{}}
+	}
+
+	ot.Run("Bolt on top of dialed connection", func(t *testing.T) {
+		c := newConnector()
+		c.SkipEncryption = true
+		c.DialContext = pipeDialer(t, nil)
+
+		_, err := c.Connect("neo4j.internal:7687", nil)
+		assertUnsupportedVersion(t, err)
+	})
+
+	ot.Run("TLS on top of dialed connection", func(t *testing.T) {
+		c := newConnector()
+		c.DialContext = pipeDialer(t, &tls.Config{Certificates: []tls.Certificate{*serverCert}})
+		c.ConfigureTls = func(_ string, config *tls.Config) (*tls.Config, error) {
+			config.ServerName = "server"
+			return config, nil
+		}
+
+		_, err := c.Connect("neo4j.internal:7687", nil)
+		assertUnsupportedVersion(t, err)
+	})
+
+	ot.Run("Dial error", func(t *testing.T) {
+		c := newConnector()
+		cause := errors.New("proxy unavailable")
+		c.DialContext = func(context.Context, string, string) (net.Conn, error) {
+			return nil, cause
+		}
+
+		_, err := c.Connect("neo4j.internal:7687", nil)
+		var connectErr *ConnectError
+		if !errors.As(err, &connectErr) || connectErr.inner != cause {
+			t.Errorf("Expected connect error caused by dialer but was %T: %v", err, err)
+		}
+	})
+}
+
+// Reads chunks of a Bolt message until the terminating empty chunk.
+func readBoltMessage(server *testutil.WebSocketFake) error {
+	var received []byte
+	for {
+		payload, err := server.ReadBinary(1)
+		if err != nil {
+			return err
+		}
+		received = append(received, payload...)
+		for len(received) >= 2 {
+			size := int(received[0])<<8 | int(received[1])
+			if size == 0 {
+				return nil
+			}
+			if len(received) < 2+size {
+				break
+			}
+			received = received[2+size:]
+		}
+	}
+}
+
+// Serves Bolt 4.4 over WebSocket until the client has said hello.
+func serveBoltOverWebSocket(t *testing.T, conn net.Conn, path string) {
+	defer conn.Close()
+	server, err := testutil.AcceptWebSocket(conn)
+	if err != nil {
+		t.Errorf("WebSocket handshake failed: %s", err)
+		return
+	}
+	if server.Path != path {
+		t.Errorf("Expected path %s but was %s", path, server.Path)
+	}
+	if _, err = server.ReadBinary(20); err != nil {
+		t.Errorf("Failed to read Bolt handshake: %s", err)
+		return
+	}
+	server.WriteFrame(true, 0x2, []byte{0, 0, 4, 4})
+	if err = readBoltMessage(server); err != nil {
+		t.Errorf("Failed to read hello: %s", err)
+		return
+	}
+	// Success with empty metadata, fragmented over two frames
+	server.WriteFrame(false, 0x2, []byte{0, 3, 0xb1})
+	server.WriteFrame(true, 0x0, []byte{0x70, 0xa0, 0, 0})
+	// Wait for client to close
+	for {
+		if _, _, err := server.ReadFrame(); err != nil {
+			return
+		}
+	}
+}
+
+func TestConnectWebSocket(ot *testing.T) {
+	serverCA := newCertAuthority(ot, "server ca")
+	serverCert := serverCA.issue(ot, "server", x509.ExtKeyUsageServerAuth)
+
+	ot.Run("Without TLS", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			serveBoltOverWebSocket(t, conn, "/bolt")
+		}()
+		c := Connector{Network: "tcp", SkipEncryption: true, WebSocket: true, WebSocketPath: "/bolt", DialTimeout: time.Second, Log: log.Void{}}
+
+		boltConn, err := c.Connect(listener.Addr().String(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		boltConn.Close()
+		<-done
+	})
+
+	ot.Run("With TLS", func(t *testing.T) {
+		done := make(chan struct{})
+		c := Connector{Network: "tcp", RootCAs: serverCA.pool, WebSocket: true, DialTimeout: time.Second, Log: log.Void{}}
+		c.DialContext = func(context.Context, string, string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go func() {
+				defer close(done)
+				serveBoltOverWebSocket(t, tls.Server(server, &tls.Config{Certificates: []tls.Certificate{*serverCert}}), "/")
+			}()
+			return client, nil
+		}
+
+		boltConn, err := c.Connect("127.0.0.1:7687", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		boltConn.Close()
+		<-done
+	})
+
+	ot.Run("Not upgraded", func(t *testing.T) {
+		c := Connector{Network: "tcp", SkipEncryption: true, WebSocket: true, DialTimeout: time.Second, Log: log.Void{}}
+		c.DialContext = func(context.Context, string, string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go func() {
+				defer server.Close()
+				server.Read(make([]byte, 1024))
+				server.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+			}()
+			return client, nil
+		}
+
+		_, err := c.Connect("127.0.0.1:7687", nil)
+		var connectErr *ConnectError
+		if !errors.As(err, &connectErr) {
+			t.Errorf("Expected connect error but was %T: %v", err, err)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package pool
+
+import (
+	"errors"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+)
+
+// Circuit breaker for a server, opens after a number of consecutive failures to stop the pool
+// from using the server for a while.
+// Not thread safe
+type breaker struct {
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker() *breaker {
+	return &breaker{state: event.CircuitClosed}
+}
+
+// Returns true if the server can be used. When the breaker has been open for long enough it
+// turns half-open and lets a single probe through.
+func (b *breaker) allow(now time.Time, openDuration time.Duration) bool {
+	switch b.state {
+	case event.CircuitOpen:
+		if now.Sub(b.openedAt) < openDuration {
+			return false
+		}
+		b.state = event.CircuitHalfOpen
+		b.probing = true
+		return true
+	case event.CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Returns true if the server should be skipped, does not change state.
+func (b *breaker) isOpen(now time.Time, openDuration time.Duration) bool {
+	switch b.state {
+	case event.CircuitOpen:
+		return now.Sub(b.openedAt) < openDuration
+	case event.CircuitHalfOpen:
+		return b.probing
+	}
+	return false
+}
+
+// Lets another probe through when the one that was let through never used the server.
+func (b *breaker) cancelProbe() {
+	if b.state == event.CircuitHalfOpen {
+		b.probing = false
+	}
+}
+
+// Successes while open are ignored, only a probe can close an open breaker.
+func (b *breaker) onSuccess() {
+	if b.state == event.CircuitOpen {
+		return
+	}
+	b.failures = 0
+	b.probing = false
+	b.state = event.CircuitClosed
+}
+
+func (b *breaker) onFailure(now time.Time, threshold int) {
+	b.failures++
+	b.probing = false
+	if b.state == event.CircuitHalfOpen || b.failures >= threshold {
+		b.state = event.CircuitOpen
+		b.openedAt = now
+	}
+}
+
+// Returns the breaker for the server, nil when circuit breaking is disabled.
+// Must be called with serversMut held.
+func (p *Pool) getBreaker(serverName string) *breaker {
+	if p.breakerThreshold <= 0 {
+		return nil
+	}
+	b := p.breakers[serverName]
+	if b == nil {
+		b = newBreaker()
+		p.breakers[serverName] = b
+	}
+	return b
+}
+
+// Must be called with serversMut held.
+func (p *Pool) breakerAllows(serverName string, now time.Time) bool {
+	b := p.getBreaker(serverName)
+	if b == nil {
+		return true
+	}
+	state := b.state
+	allowed := b.allow(now, p.breakerOpenDuration)
+	p.notifyBreakerChanged(serverName, state, b.state)
+	return allowed
+}
+
+// Must be called with serversMut held.
+func (p *Pool) breakerProbeCancelled(serverName string) {
+	if b := p.breakers[serverName]; b != nil {
+		b.cancelProbe()
+	}
+}
+
+// Must be called with serversMut held.
+func (p *Pool) breakerSucceeded(serverName string) {
+	b := p.getBreaker(serverName)
+	if b == nil {
+		return
+	}
+	state := b.state
+	b.onSuccess()
+	p.notifyBreakerChanged(serverName, state, b.state)
+}
+
+// Must be called with serversMut held.
+func (p *Pool) breakerFailed(serverName string, now time.Time) {
+	b := p.getBreaker(serverName)
+	if b == nil {
+		return
+	}
+	state := b.state
+	b.onFailure(now, p.breakerThreshold)
+	p.notifyBreakerChanged(serverName, state, b.state)
+}
+
+// Failed is called when work on a borrowed connection failed with err before the connection is
+// returned. Errors telling that the server is unable to serve count as a failure of the server,
+// other errors like syntax errors do not. Either way the connection is not counted as a success
+// when it is returned.
+func (p *Pool) Failed(c db.Connection, err error) {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	if p.breakerThreshold <= 0 {
+		return
+	}
+	counted := p.failedConns[c]
+	if !counted && isServerFailure(err) {
+		p.breakerFailed(c.ServerName(), p.now())
+		counted = true
+	}
+	p.failedConns[c] = counted
+}
+
+// Records the outcome of the work on a connection that is returned.
+// Must be called with serversMut held.
+func (p *Pool) breakerReturned(serverName string, c db.Connection, isAlive bool, now time.Time) {
+	counted, failed := p.failedConns[c]
+	delete(p.failedConns, c)
+	switch {
+	case isAlive && !failed:
+		p.breakerSucceeded(serverName)
+	case !isAlive && !counted:
+		p.breakerFailed(serverName, now)
+	}
+}
+
+func isServerFailure(err error) bool {
+	var dbErr *db.Neo4jError
+	if !errors.As(err, &dbErr) {
+		return false
+	}
+	switch dbErr.Classification() {
+	case "DatabaseError":
+		return true
+	case "TransientError":
+		// Other transient errors like deadlocks are caused by the workload, not by the server
+		category := dbErr.Category()
+		return category == "General" || category == "Cluster"
+	}
+	return false
+}
+
+func (p *Pool) notifyBreakerChanged(serverName, from, to string) {
+	if from == to {
+		return
+	}
+	if to == event.CircuitOpen {
+		p.log.Warnf(log.Pool, p.logId, "Circuit breaker for %s is %s", serverName, to)
+	} else {
+		p.log.Infof(log.Pool, p.logId, "Circuit breaker for %s is %s", serverName, to)
+	}
+	p.listener.OnEvent(event.CircuitBreakerChanged{Server: serverName, State: to})
+}
+
+// CircuitOpen returns true if the circuit breaker for the server is open, connections to the
+// server will not be attempted until the breaker lets a probe through.
+func (p *Pool) CircuitOpen(serverName string) bool {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	b := p.breakers[serverName]
+	return b != nil && b.isOpen(p.now(), p.breakerOpenDuration)
+}
@@ -46,3 +46,11 @@ type PoolClosed struct {
 func (e *PoolClosed) Error() string {
 	return "Pool closed"
 }
+
+type CircuitOpen struct {
+	server string
+}
+
+func (e *CircuitOpen) Error() string {
+	return fmt.Sprintf("Circuit breaker for %s is open", e.server)
+}
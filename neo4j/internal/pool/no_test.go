@@ -64,3 +64,10 @@ func assertNumberOfIdle(t *testing.T, p *Pool, serverName string, expectedNum in
 		t.Fatalf("Expected number of idle conns on %s to be %d but was %d", serverName, expectedNum, actualNum)
 	}
 }
+
+func assertNumberOfConnects(t *testing.T, actualNum, expectedNum int) {
+	t.Helper()
+	if actualNum != expectedNum {
+		t.Fatalf("Expected number of connects to be %d but was %d", expectedNum, actualNum)
+	}
+}
@@ -32,6 +32,8 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/loadbalance"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
 
@@ -55,6 +57,16 @@ type Pool struct {
 	closed     bool
 	log        log.Logger
 	logId      string
+	listener   event.Listener
+	strategy   loadbalance.Strategy
+	// Guarded by serversMut, connections that this returns true for are closed when returned
+	expired func(db.Connection) bool
+	// Guarded by serversMut, kept separately from servers since those are removed when unused
+	breakers            map[string]*breaker
+	breakerThreshold    int
+	breakerOpenDuration time.Duration
+	// Guarded by serversMut, borrowed connections that failed, true if counted by the breaker
+	failedConns map[db.Connection]bool
 }
 
 type serverPenalty struct {
@@ -62,20 +74,32 @@ type serverPenalty struct {
 	penalty uint32
 }
 
-func New(maxSize int, maxAge time.Duration, connect Connect, logger log.Logger, logId string) *Pool {
+// New creates a new pool. If strategy is nil the pool orders servers by a penalty based on
+// number of connections, recent connect failures and when the server was last used.
+// A circuit breaker per server opens after breakerThreshold consecutive failed connects, failed
+// work or dead connections and lets a probe through after breakerOpenDuration. Zero or negative
+// breakerThreshold disables circuit breaking.
+func New(maxSize int, maxAge time.Duration, connect Connect, logger log.Logger, logId string, listener event.Listener, strategy loadbalance.Strategy, breakerThreshold int, breakerOpenDuration time.Duration) *Pool {
 	// Means infinite life, simplifies checking later on
 	if maxAge <= 0 {
 		maxAge = 1<<63 - 1
 	}
 
 	p := &Pool{
-		maxSize: maxSize,
-		maxAge:  maxAge,
-		connect: connect,
-		servers: make(map[string]*server),
-		now:     time.Now,
-		logId:   logId,
-		log:     logger,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		connect:  connect,
+		servers:  make(map[string]*server),
+		now:      time.Now,
+		logId:    logId,
+		log:      logger,
+		listener: listener,
+		strategy: strategy,
+
+		breakers:            make(map[string]*breaker),
+		failedConns:         make(map[db.Connection]bool),
+		breakerThreshold:    breakerThreshold,
+		breakerOpenDuration: breakerOpenDuration,
 	}
 	p.log.Infof(log.Pool, p.logId, "Created")
 	return p
@@ -90,7 +114,7 @@ func (p *Pool) Close() {
 	// Go through each server and close all connections to it
 	p.serversMut.Lock()
 	for n, s := range p.servers {
-		s.closeAll()
+		p.notifyClosed(n, event.ReasonClosed, s.closeAll())
 		delete(p.servers, n)
 	}
 	p.serversMut.Unlock()
@@ -138,7 +162,75 @@ func (p *Pool) CleanUp() {
 	defer p.serversMut.Unlock()
 	now := p.now()
 	for n, s := range p.servers {
-		s.removeIdleOlderThan(now, p.maxAge)
+		p.notifyClosed(n, event.ReasonExpired, s.removeIdleOlderThan(now, p.maxAge))
+		if s.size() == 0 && !s.hasFailedConnect(now) {
+			delete(p.servers, n)
+		}
+	}
+	// Breakers without failures carry no information
+	for n, b := range p.breakers {
+		if b.state == event.CircuitClosed && b.failures == 0 {
+			delete(p.breakers, n)
+		}
+	}
+}
+
+// Expire closes idle connections that expired returns true for. Busy connections that it returns
+// true for are closed when they are returned and idle connections that it returns true for are
+// not added to the pool. Replaces the function of any previous call.
+func (p *Pool) Expire(expired func(c db.Connection) bool) {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	if p.closed {
+		return
+	}
+	p.expired = expired
+	for n, s := range p.servers {
+		num := s.removeIdleExpired(expired)
+		if num > 0 {
+			p.log.Infof(log.Pool, p.logId, "Closed %d expired connections to %s", num, n)
+		}
+		p.notifyClosed(n, event.ReasonExpired, num)
+	}
+}
+
+// CloseIdle closes connections that have been idle for maxIdleTime or longer. The keep most
+// recently used idle connections on each server are kept regardless of idle time.
+func (p *Pool) CloseIdle(maxIdleTime time.Duration, keep int) {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	now := p.now()
+	for n, s := range p.servers {
+		num := s.removeIdleSince(now, maxIdleTime, keep)
+		if num > 0 {
+			p.log.Infof(log.Pool, p.logId, "Closed %d idle connections to %s", num, n)
+		}
+		p.notifyClosed(n, event.ReasonIdle, num)
+		if s.size() == 0 && !s.hasFailedConnect(now) {
+			delete(p.servers, n)
+		}
+	}
+}
+
+// CloseUnknown closes all idle connections to servers that are not among the known servers.
+// Busy connections to those servers will be closed by a later call when they have been returned.
+func (p *Pool) CloseUnknown(knownServers []string) {
+	known := make(map[string]bool, len(knownServers))
+	for _, s := range knownServers {
+		known[s] = true
+	}
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	now := p.now()
+	for n, s := range p.servers {
+		if known[n] {
+			continue
+		}
+		num := s.removeIdleSince(now, 0, 0)
+		if num > 0 {
+			p.log.Infof(log.Pool, p.logId, "Closed %d idle connections to unknown server %s", num, n)
+		}
+		p.notifyClosed(n, event.ReasonUnknown, num)
 		if s.size() == 0 && !s.hasFailedConnect(now) {
 			delete(p.servers, n)
 		}
@@ -152,6 +244,10 @@ func (p *Pool) tryBorrow(serverName string, boltLogger log.BoltLogger) (db.Conne
 	p.serversMut.Lock()
 	defer p.serversMut.Unlock()
 
+	if !p.breakerAllows(serverName, p.now()) {
+		return nil, &CircuitOpen{server: serverName}
+	}
+
 	srv := p.servers[serverName]
 	if srv != nil {
 		// Try to get an existing idle connection
@@ -160,6 +256,8 @@ func (p *Pool) tryBorrow(serverName string, boltLogger log.BoltLogger) (db.Conne
 			return c, nil
 		}
 		if srv.size() >= p.maxSize {
+			// Nothing was probed, let the next borrow probe the server
+			p.breakerProbeCancelled(serverName)
 			return nil, &PoolFull{servers: []string{serverName}}
 		}
 	} else {
@@ -175,15 +273,93 @@ func (p *Pool) tryBorrow(serverName string, boltLogger log.BoltLogger) (db.Conne
 		// Failed to connect, keep track that it was bad for a while
 		srv.notifyFailedConnect(p.now())
 		p.log.Warnf(log.Pool, p.logId, "Failed to connect to %s: %s", serverName, err)
+		p.listener.OnEvent(event.ServerPenalised{Server: serverName, Err: err})
+		p.breakerFailed(serverName, p.now())
 		return nil, err
 	}
 
 	// Ok, got a connection, register the connection
 	srv.registerBusy(c)
 	srv.notifySuccesfulConnect()
+	p.breakerSucceeded(serverName)
+	p.listener.OnEvent(event.ConnectionOpened{Server: serverName})
 	return c, nil
 }
 
+// FillIdle makes sure that each of the named servers has at least num idle connections by
+// connecting new ones. The number of connections per server is still limited by the maximum
+// pool size and servers with a recently failed connect are skipped. Connects are done without
+// holding the lock on the servers to avoid blocking borrowers while connecting.
+// Returns the last connect error if any.
+func (p *Pool) FillIdle(serverNames []string, num int) error {
+	var err error
+	for _, serverName := range serverNames {
+		for p.needsIdle(serverName, num) {
+			if p.closed {
+				return &PoolClosed{}
+			}
+			p.log.Infof(log.Pool, p.logId, "Connecting to %s to keep %d idle connections", serverName, num)
+			c, connectErr := p.connect(serverName, nil)
+			if connectErr != nil {
+				p.notifyFailedConnect(serverName, connectErr)
+				err = connectErr
+				break
+			}
+			if !p.registerIdle(serverName, c, num) {
+				// Pool filled up by borrowers or the connection expired while connecting
+				go c.Close()
+				break
+			}
+		}
+	}
+	return err
+}
+
+func (p *Pool) needsIdle(serverName string, num int) bool {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	if b := p.breakers[serverName]; b != nil && b.isOpen(p.now(), p.breakerOpenDuration) {
+		return false
+	}
+	srv := p.servers[serverName]
+	if srv == nil {
+		return num > 0 && p.maxSize > 0
+	}
+	return srv.numIdle() < num && srv.size() < p.maxSize && !srv.hasFailedConnect(p.now())
+}
+
+func (p *Pool) registerIdle(serverName string, c db.Connection, num int) bool {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	srv := p.servers[serverName]
+	if srv == nil {
+		srv = &server{}
+		p.servers[serverName] = srv
+	}
+	if p.closed || srv.numIdle() >= num || srv.size() >= p.maxSize || (p.expired != nil && p.expired(c)) {
+		return false
+	}
+	srv.registerIdle(c, p.now())
+	srv.notifySuccesfulConnect()
+	p.breakerSucceeded(serverName)
+	p.listener.OnEvent(event.ConnectionOpened{Server: serverName})
+	return true
+}
+
+func (p *Pool) notifyFailedConnect(serverName string, err error) {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	srv := p.servers[serverName]
+	if srv == nil {
+		srv = &server{}
+		p.servers[serverName] = srv
+	}
+	srv.notifyFailedConnect(p.now())
+	p.log.Warnf(log.Pool, p.logId, "Failed to connect to %s: %s", serverName, err)
+	p.listener.OnEvent(event.ServerPenalised{Server: serverName, Err: err})
+	p.breakerFailed(serverName, p.now())
+}
+
 func (p *Pool) getPenaltiesForServers(serverNames []string) []serverPenalty {
 	p.serversMut.Lock()
 	defer p.serversMut.Unlock()
@@ -196,7 +372,7 @@ func (p *Pool) getPenaltiesForServers(serverNames []string) []serverPenalty {
 		penalties[i].name = n
 		if s != nil {
 			// Make sure that we don't get a too old connection
-			s.removeIdleOlderThan(now, p.maxAge)
+			p.notifyClosed(n, event.ReasonExpired, s.removeIdleOlderThan(now, p.maxAge))
 			penalties[i].penalty = s.calculatePenalty(now)
 		} else {
 			penalties[i].penalty = newConnectionPenalty
@@ -205,6 +381,64 @@ func (p *Pool) getPenaltiesForServers(serverNames []string) []serverPenalty {
 	return penalties
 }
 
+func (p *Pool) getStatsForServers(serverNames []string) []loadbalance.ServerStats {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+
+	stats := make([]loadbalance.ServerStats, len(serverNames))
+	now := p.now()
+	for i, n := range serverNames {
+		s := p.servers[n]
+		if s != nil {
+			// Make sure that we don't get a too old connection
+			p.notifyClosed(n, event.ReasonExpired, s.removeIdleOlderThan(now, p.maxAge))
+			stats[i] = s.stats(n, now)
+		} else {
+			stats[i].Server = n
+		}
+	}
+	return stats
+}
+
+// Returns the servers in the order they should be tried.
+func (p *Pool) orderServers(serverNames []string) []string {
+	if p.strategy != nil {
+		ordered := candidatesOnly(serverNames, p.strategy.Order(p.getStatsForServers(serverNames)))
+		if len(ordered) > 0 {
+			return ordered
+		}
+		p.log.Warnf(log.Pool, p.logId, "Load balancing strategy chose none of %v, using default order", serverNames)
+	}
+
+	// Retrieve penalty for each server
+	penalties := p.getPenaltiesForServers(serverNames)
+	// Sort server penalties by lowest penalty
+	sort.Slice(penalties, func(i, j int) bool {
+		return penalties[i].penalty < penalties[j].penalty
+	})
+	ordered := make([]string, len(penalties))
+	for i, s := range penalties {
+		ordered[i] = s.name
+	}
+	return ordered
+}
+
+// Returns the servers in ordered that are among the candidates, each one once.
+func candidatesOnly(candidates, ordered []string) []string {
+	isCandidate := make(map[string]bool, len(candidates))
+	for _, s := range candidates {
+		isCandidate[s] = true
+	}
+	filtered := make([]string, 0, len(ordered))
+	for _, s := range ordered {
+		if isCandidate[s] {
+			filtered = append(filtered, s)
+			isCandidate[s] = false
+		}
+	}
+	return filtered
+}
+
 func (p *Pool) tryAnyIdle(serverNames []string) db.Connection {
 	p.serversMut.Lock()
 	defer p.serversMut.Unlock()
@@ -240,17 +474,10 @@ func (p *Pool) Borrow(ctx context.Context, serverNames []string, wait bool, bolt
 	}
 	p.log.Debugf(log.Pool, p.logId, "Trying to borrow connection from %s", serverNames)
 
-	// Retrieve penalty for each server
-	penalties := p.getPenaltiesForServers(serverNames)
-	// Sort server penalties by lowest penalty
-	sort.Slice(penalties, func(i, j int) bool {
-		return penalties[i].penalty < penalties[j].penalty
-	})
-
 	var err error
 	var conn db.Connection
-	for _, s := range penalties {
-		conn, err = p.tryBorrow(s.name, boltLogger)
+	for _, s := range p.orderServers(serverNames) {
+		conn, err = p.tryBorrow(s, boltLogger)
 		if err == nil {
 			return conn, nil
 		}
@@ -335,14 +562,29 @@ func (p *Pool) unreg(serverName string, c db.Connection, now time.Time) {
 	}
 }
 
-func (p *Pool) removeIdleOlderThanOnServer(serverName string, now time.Time, maxAge time.Duration) {
+func (p *Pool) removeIdleOlderThanOnServer(serverName string, now time.Time, maxAge time.Duration, reason string) {
 	p.serversMut.Lock()
 	defer p.serversMut.Unlock()
 	server := p.servers[serverName]
 	if server == nil {
 		return
 	}
-	server.removeIdleOlderThan(now, maxAge)
+	p.notifyClosed(serverName, reason, server.removeIdleOlderThan(now, maxAge))
+}
+
+func (p *Pool) notifyClosed(serverName, reason string, num int) {
+	for i := 0; i < num; i++ {
+		p.listener.OnEvent(event.ConnectionClosed{Server: serverName, Reason: reason})
+	}
+}
+
+func (p *Pool) handOver(serverName string, c db.Connection) {
+	p.serversMut.Lock()
+	defer p.serversMut.Unlock()
+	server := p.servers[serverName]
+	if server != nil {
+		server.handOverBusy(c)
+	}
 }
 
 func (p *Pool) Return(c db.Connection) {
@@ -363,14 +605,16 @@ func (p *Pool) Return(c db.Connection) {
 	maxAge := p.maxAge
 	now := p.now()
 	age := now.Sub(c.Birthdate())
+	reason := event.ReasonExpired
 	if !isAlive {
 		// Since this connection has died all other connections that connected before this one
 		// might also be bad, remove the idle ones.
 		if age < maxAge {
 			maxAge = age
 		}
+		reason = event.ReasonDead
 	}
-	p.removeIdleOlderThanOnServer(serverName, now, maxAge)
+	p.removeIdleOlderThanOnServer(serverName, now, maxAge, reason)
 
 	// Prepare connection for being used by someone else if is alive.
 	// Since reset could find the connection to be in a bad state or non-recoverable state,
@@ -381,8 +625,17 @@ func (p *Pool) Return(c db.Connection) {
 	}
 
 	// Shouldn't return a too old or dead connection back to the pool
-	if !isAlive || age >= p.maxAge {
+	p.serversMut.Lock()
+	p.breakerReturned(serverName, c, isAlive, now)
+	expired := p.expired != nil && p.expired(c)
+	p.serversMut.Unlock()
+
+	if !isAlive || age >= p.maxAge || expired {
 		p.unreg(serverName, c, now)
+		if !isAlive {
+			reason = event.ReasonDead
+		}
+		p.notifyClosed(serverName, reason, 1)
 		p.log.Infof(log.Pool, p.logId, "Unregistering dead or too old connection to %s", serverName)
 		// Returning here could cause a waiting thread to wait until it times out, to do it
 		// properly we could wake up threads that waits on the server and wake them up if there
@@ -400,6 +653,7 @@ func (p *Pool) Return(c db.Connection) {
 				qitem.conn = c
 				p.queue.Remove(e)
 				p.queueMut.Unlock()
+				p.handOver(serverName, c)
 				qitem.wakeup <- true
 				return
 			}
@@ -412,7 +666,7 @@ func (p *Pool) Return(c db.Connection) {
 	defer p.serversMut.Unlock()
 	server := p.servers[serverName]
 	if server != nil { // Strange when server not found
-		server.returnBusy(c)
+		server.returnBusy(c, now)
 	} else {
 		p.log.Warnf(log.Pool, p.logId, "Server %s not found", serverName)
 	}
@@ -23,12 +23,15 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/loadbalance"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
 
@@ -49,7 +52,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 	}
 
 	ot.Run("Single thread borrow+return", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		serverNames := []string{"srv1"}
@@ -65,7 +68,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 	})
 
 	ot.Run("First thread borrows, second thread blocks on borrow", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		serverNames := []string{"srv1"}
@@ -100,7 +103,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 	})
 
 	ot.Run("First thread borrows, second thread should not block on borrow without wait", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		serverNames := []string{"srv1"}
@@ -120,7 +123,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 
 	ot.Run("Multiple threads borrows and returns randomly", func(t *testing.T) {
 		maxConns := 2
-		p := New(maxConns, maxAge, succeedingConnect, logger, "poolid")
+		p := New(maxConns, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		serverNames := []string{"srv1"}
 		numWorkers := 5
@@ -152,7 +155,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 	})
 
 	ot.Run("Failing connect", func(t *testing.T) {
-		p := New(2, maxAge, failingConnect, logger, "poolid")
+		p := New(2, maxAge, failingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		serverNames := []string{"srv1"}
 		c, err := p.Borrow(context.Background(), serverNames, true, nil)
@@ -164,7 +167,7 @@ func TestPoolBorrowReturn(ot *testing.T) {
 	})
 
 	ot.Run("Cancel Borrow", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		c1, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
 		ctx, cancel := context.WithCancel(context.Background())
@@ -203,7 +206,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	}
 
 	ot.Run("Use order of named servers as priority when creating new servers", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		serverNames := []string{"srvA", "srvB", "srvC", "srvD"}
@@ -214,7 +217,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	})
 
 	ot.Run("Do not put dead connection back to server", func(t *testing.T) {
-		p := New(2, maxAge, succeedingConnect, logger, "poolid")
+		p := New(2, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		serverNames := []string{"srvA"}
@@ -228,7 +231,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	})
 
 	ot.Run("Do not put too old connection back to server", func(t *testing.T) {
-		p := New(2, maxAge, succeedingConnect, logger, "poolid")
+		p := New(2, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate.Add(maxAge * 2) }
 		defer p.Close()
 		serverNames := []string{"srvA"}
@@ -241,7 +244,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	})
 
 	ot.Run("Returning dead connection to server should remove older idle connections", func(t *testing.T) {
-		p := New(3, 0, succeedingConnect, logger, "poolid")
+		p := New(3, 0, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		// Trigger creation of three connections on the same server
 		c1, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
 		c2, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
@@ -266,7 +269,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	})
 
 	ot.Run("Do not borrow too old connections", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		nowMut := sync.Mutex{}
 		now := birthdate
 		p.now = func() time.Time {
@@ -291,7 +294,7 @@ func TestPoolResourceUsage(ot *testing.T) {
 	})
 
 	ot.Run("Add servers when existing servers are full", func(t *testing.T) {
-		p := New(1, maxAge, succeedingConnect, logger, "poolid")
+		p := New(1, maxAge, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		p.now = func() time.Time { return birthdate }
 		defer p.Close()
 		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
@@ -319,7 +322,7 @@ func TestPoolCleanup(ot *testing.T) {
 	}
 
 	ot.Run("Should remove servers with only idle too old connections", func(t *testing.T) {
-		p := New(0, maxLife, succeedingConnect, logger, "poolid")
+		p := New(0, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		defer p.Close()
 		p.now = func() time.Time { return birthdate }
 		c1, c2 := borrowConnections(t, p)
@@ -336,7 +339,7 @@ func TestPoolCleanup(ot *testing.T) {
 	})
 
 	ot.Run("Should not remove servers with busy connections", func(t *testing.T) {
-		p := New(0, maxLife, succeedingConnect, logger, "poolid")
+		p := New(0, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		defer p.Close()
 		p.now = func() time.Time { return birthdate }
 		_, c2 := borrowConnections(t, p)
@@ -355,7 +358,7 @@ func TestPoolCleanup(ot *testing.T) {
 		failingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
 			return nil, errors.New("an error")
 		}
-		p := New(0, maxLife, failingConnect, logger, "poolid")
+		p := New(0, maxLife, failingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
 		defer p.Close()
 		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
 		assertNoConnection(t, c1, err)
@@ -376,3 +379,480 @@ func TestPoolCleanup(ot *testing.T) {
 		assertNumberOfServers(t, p, 0)
 	})
 }
+
+func TestPoolEvents(ot *testing.T) {
+	birthdate := time.Now()
+	maxLife := 1 * time.Second
+	succeedingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: birthdate}, nil
+	}
+
+	ot.Run("Should notify opened and closed connections", func(t *testing.T) {
+		listener := &testutil.ListenerFake{}
+		p := New(2, maxLife, succeedingConnect, logger, "poolid", listener, nil, 0, 0)
+		p.now = func() time.Time { return birthdate }
+		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertConnection(t, c1, err)
+		c2, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertConnection(t, c2, err)
+		c1.(*testutil.ConnFake).Alive = false
+		p.Return(c1)
+		p.Close()
+
+		expected := []event.Event{
+			event.ConnectionOpened{Server: "A"},
+			event.ConnectionOpened{Server: "A"},
+			event.ConnectionClosed{Server: "A", Reason: event.ReasonDead},
+			event.ConnectionClosed{Server: "A", Reason: event.ReasonClosed},
+		}
+		if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+			t.Errorf("Expected events %v but was %v", expected, events)
+		}
+	})
+
+	ot.Run("Should notify expired connections on cleanup", func(t *testing.T) {
+		listener := &testutil.ListenerFake{}
+		p := New(1, maxLife, succeedingConnect, logger, "poolid", listener, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertConnection(t, c1, err)
+		p.Return(c1)
+		p.now = func() time.Time { return birthdate.Add(maxLife).Add(1 * time.Second) }
+		p.CleanUp()
+
+		events := listener.Events()
+		if len(events) != 2 || events[1] != (event.ConnectionClosed{Server: "A", Reason: event.ReasonExpired}) {
+			t.Errorf("Expected expired connection event but was %v", events)
+		}
+	})
+
+	ot.Run("Should notify penalised server on failed connect", func(t *testing.T) {
+		listener := &testutil.ListenerFake{}
+		connectErr := errors.New("an error")
+		failingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+			return nil, connectErr
+		}
+		p := New(1, maxLife, failingConnect, logger, "poolid", listener, nil, 0, 0)
+		defer p.Close()
+		c, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertNoConnection(t, c, err)
+
+		expected := []event.Event{event.ServerPenalised{Server: "A", Err: connectErr}}
+		if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+			t.Errorf("Expected events %v but was %v", expected, events)
+		}
+	})
+}
+
+func TestPoolFillIdle(ot *testing.T) {
+	birthdate := time.Now()
+	maxLife := 1 * time.Second
+	succeedingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: birthdate}, nil
+	}
+
+	ot.Run("Should connect until number of idle connections is reached", func(t *testing.T) {
+		p := New(3, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertConnection(t, c1, err)
+
+		err = p.FillIdle([]string{"A", "B"}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNumberOfServers(t, p, 2)
+		assertNumberOfIdle(t, p, "A", 2)
+		assertNumberOfIdle(t, p, "B", 2)
+
+		// Borrowing should use the idle connection
+		c2, err := p.Borrow(context.Background(), []string{"B"}, true, nil)
+		assertConnection(t, c2, err)
+		assertNumberOfIdle(t, p, "B", 1)
+	})
+
+	ot.Run("Should respect max pool size", func(t *testing.T) {
+		p := New(2, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		c1, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		assertConnection(t, c1, err)
+
+		err = p.FillIdle([]string{"A"}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertNumberOfIdle(t, p, "A", 1)
+	})
+
+	ot.Run("Should return connect error and skip penalised server", func(t *testing.T) {
+		numConnects := 0
+		connectErr := errors.New("an error")
+		failingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+			numConnects++
+			return nil, connectErr
+		}
+		p := New(2, maxLife, failingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+
+		err := p.FillIdle([]string{"A"}, 1)
+		if err != connectErr {
+			t.Errorf("Should get connect error back but got: %s", err)
+		}
+		err = p.FillIdle([]string{"A"}, 1)
+		if err != nil {
+			t.Errorf("Should skip recently failed server but got: %s", err)
+		}
+		if numConnects != 1 {
+			t.Errorf("Expected one connect attempt but was %d", numConnects)
+		}
+	})
+}
+
+func TestPoolCloseIdle(ot *testing.T) {
+	birthdate := time.Now()
+	maxLife := 1 * time.Hour
+	maxIdleTime := 1 * time.Minute
+	succeedingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: birthdate}, nil
+	}
+
+	ot.Run("Should close connections idle for too long", func(t *testing.T) {
+		p := New(3, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		c1, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		c2, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		c3, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+		p.Return(c1)
+		p.now = func() time.Time { return birthdate.Add(maxIdleTime / 2) }
+		p.Return(c2)
+
+		// Only c1 has been idle long enough
+		p.now = func() time.Time { return birthdate.Add(maxIdleTime) }
+		p.CloseIdle(maxIdleTime, 0)
+		assertNumberOfIdle(t, p, "A", 1)
+
+		// Borrowed connection should be kept
+		p.now = func() time.Time { return birthdate.Add(2 * maxIdleTime) }
+		p.CloseIdle(maxIdleTime, 0)
+		assertNumberOfServers(t, p, 1)
+		assertNumberOfIdle(t, p, "A", 0)
+		p.Return(c3)
+		assertNumberOfIdle(t, p, "A", 1)
+	})
+
+	ot.Run("Should keep requested number of idle connections", func(t *testing.T) {
+		p := New(3, maxLife, succeedingConnect, logger, "poolid", event.Void{}, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		err := p.FillIdle([]string{"A"}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.now = func() time.Time { return birthdate.Add(maxIdleTime) }
+		p.CloseIdle(maxIdleTime, 2)
+		assertNumberOfIdle(t, p, "A", 2)
+	})
+
+	ot.Run("Should close idle connections to unknown servers", func(t *testing.T) {
+		listener := &testutil.ListenerFake{}
+		p := New(3, maxLife, succeedingConnect, logger, "poolid", listener, nil, 0, 0)
+		defer p.Close()
+		p.now = func() time.Time { return birthdate }
+		err := p.FillIdle([]string{"A", "B"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, _ := p.Borrow(context.Background(), []string{"C"}, true, nil)
+
+		p.CloseUnknown([]string{"A"})
+		assertNumberOfServers(t, p, 2)
+		assertNumberOfIdle(t, p, "A", 1)
+		assertNumberOfIdle(t, p, "C", 0)
+		events := listener.Events()
+		if events[len(events)-1] != (event.ConnectionClosed{Server: "B", Reason: event.ReasonUnknown}) {
+			t.Errorf("Expected closed connection event but was %v", events)
+		}
+
+		// Busy connection should be closed when returned and cleaned up again
+		p.Return(c)
+		p.CloseUnknown([]string{"A"})
+		assertNumberOfServers(t, p, 1)
+	})
+}
+
+func TestPoolExpire(t *testing.T) {
+	birthdate := time.Now()
+	token := "first"
+	connect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		auth := map[string]interface{}{"credentials": token}
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: birthdate, Auth: auth}, nil
+	}
+	expired := func(c db.Connection) bool {
+		return c.(db.AuthTokenHolder).AuthToken()["credentials"] != token
+	}
+	listener := &testutil.ListenerFake{}
+	p := New(3, time.Hour, connect, logger, "poolid", listener, nil, 0, 0)
+	defer p.Close()
+	c1, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+	c2, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+	p.Return(c1)
+
+	// Idle connection is closed immediately
+	token = "second"
+	p.Expire(expired)
+	assertNumberOfIdle(t, p, "A", 0)
+	events := listener.Events()
+	if events[len(events)-1] != (event.ConnectionClosed{Server: "A", Reason: event.ReasonExpired}) {
+		t.Errorf("Expected closed connection event but was %v", events)
+	}
+
+	// Busy connection is closed when returned
+	p.Return(c2)
+	assertNumberOfServers(t, p, 0)
+
+	// Connections with the current token are kept
+	c3, _ := p.Borrow(context.Background(), []string{"A"}, true, nil)
+	p.Return(c3)
+	assertNumberOfIdle(t, p, "A", 1)
+
+	// Idle connections that expire while connecting are not kept
+	token = "third"
+	if err := p.FillIdle([]string{"B"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertNumberOfIdle(t, p, "B", 1)
+	connect2 := p.connect
+	p.connect = func(s string, l log.BoltLogger) (db.Connection, error) {
+		c, err := connect2(s, l)
+		token = "fourth"
+		return c, err
+	}
+	if err := p.FillIdle([]string{"C"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertNumberOfIdle(t, p, "C", 0)
+}
+
+type strategyFake struct {
+	candidates []loadbalance.ServerStats
+	order      []string
+}
+
+func (s *strategyFake) Order(candidates []loadbalance.ServerStats) []string {
+	s.candidates = candidates
+	return s.order
+}
+
+func TestPoolStrategy(t *testing.T) {
+	birthdate := time.Now()
+	succeedingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: birthdate}, nil
+	}
+	strategy := &strategyFake{order: []string{"B", "A"}}
+	p := New(2, time.Hour, succeedingConnect, logger, "poolid", event.Void{}, strategy, 0, 0)
+	defer p.Close()
+	p.now = func() time.Time { return birthdate }
+	p.FillIdle([]string{"A"}, 1)
+
+	c2, err := p.Borrow(context.Background(), []string{"A", "B"}, true, nil)
+	assertConnection(t, c2, err)
+	if c2.ServerName() != "B" {
+		t.Errorf("Should have borrowed from server chosen by strategy but was %s", c2.ServerName())
+	}
+	expected := []loadbalance.ServerStats{{Server: "A", Idle: 1}, {Server: "B"}}
+	if !reflect.DeepEqual(strategy.candidates, expected) {
+		t.Errorf("Expected candidates %+v but was %+v", expected, strategy.candidates)
+	}
+}
+
+func TestPoolStrategyChoosingUnknownServers(t *testing.T) {
+	succeedingConnect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: time.Now()}, nil
+	}
+	strategy := &strategyFake{order: []string{"C", "B", "B"}}
+	p := New(2, time.Hour, succeedingConnect, logger, "poolid", event.Void{}, strategy, 0, 0)
+	defer p.Close()
+
+	if order := p.orderServers([]string{"A", "B"}); !reflect.DeepEqual(order, []string{"B"}) {
+		t.Errorf("Expected only candidates in order but was %v", order)
+	}
+
+	// Falls back to the default order when none of the servers is a candidate
+	strategy.order = []string{"C"}
+	c, err := p.Borrow(context.Background(), []string{"A"}, true, nil)
+	assertConnection(t, c, err)
+	if c.ServerName() != "A" {
+		t.Errorf("Should have borrowed from candidate but was %s", c.ServerName())
+	}
+}
+
+func TestPoolCircuitBreaker(ot *testing.T) {
+	now := time.Now()
+	failing := map[string]bool{}
+	numConnects := map[string]int{}
+	connect := func(s string, _ log.BoltLogger) (db.Connection, error) {
+		numConnects[s]++
+		if failing[s] {
+			return nil, errors.New("connect fail")
+		}
+		return &testutil.ConnFake{Name: s, Alive: true, Birth: now}, nil
+	}
+
+	ot.Run("Opens after threshold and probes when half-open", func(t *testing.T) {
+		listener := &testutil.ListenerFake{}
+		p := New(2, time.Hour, connect, logger, "poolid", listener, nil, 2, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		failing["A"] = true
+		numConnects["A"] = 0
+
+		for i := 0; i < 3; i++ {
+			_, err := p.Borrow(context.Background(), []string{"A"}, false, nil)
+			if err == nil {
+				t.Fatal("Expected borrow to fail")
+			}
+		}
+		assertNumberOfConnects(t, numConnects["A"], 2)
+		assertTrue(t, p.CircuitOpen("A"))
+
+		// After open duration a single probe should be let through
+		p.now = func() time.Time { return now.Add(time.Minute) }
+		assertFalse(t, p.CircuitOpen("A"))
+		failing["A"] = false
+		c, err := p.Borrow(context.Background(), []string{"A"}, false, nil)
+		assertConnection(t, c, err)
+		assertNumberOfConnects(t, numConnects["A"], 3)
+		assertFalse(t, p.CircuitOpen("A"))
+
+		expected := []event.Event{
+			event.ServerPenalised{Server: "A", Err: listener.Events()[0].(event.ServerPenalised).Err},
+			event.ServerPenalised{Server: "A", Err: listener.Events()[0].(event.ServerPenalised).Err},
+			event.CircuitBreakerChanged{Server: "A", State: event.CircuitOpen},
+			event.CircuitBreakerChanged{Server: "A", State: event.CircuitHalfOpen},
+			event.CircuitBreakerChanged{Server: "A", State: event.CircuitClosed},
+			event.ConnectionOpened{Server: "A"},
+		}
+		if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+			t.Errorf("Expected events %v but was %v", expected, events)
+		}
+	})
+
+	ot.Run("Failed probe opens again", func(t *testing.T) {
+		p := New(2, time.Hour, connect, logger, "poolid", event.Void{}, nil, 1, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		failing["B"] = true
+		p.Borrow(context.Background(), []string{"B"}, false, nil)
+		assertTrue(t, p.CircuitOpen("B"))
+
+		p.now = func() time.Time { return now.Add(time.Minute) }
+		p.Borrow(context.Background(), []string{"B"}, false, nil)
+		assertTrue(t, p.CircuitOpen("B"))
+	})
+
+	ot.Run("Full pool does not use up the probe", func(t *testing.T) {
+		p := New(1, time.Hour, connect, logger, "poolid", event.Void{}, nil, 1, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		c, err := p.Borrow(context.Background(), []string{"F"}, false, nil)
+		assertConnection(t, c, err)
+		p.serversMut.Lock()
+		p.breakerFailed("F", now)
+		p.serversMut.Unlock()
+		assertTrue(t, p.CircuitOpen("F"))
+
+		p.now = func() time.Time { return now.Add(time.Minute) }
+		for i := 0; i < 2; i++ {
+			_, err = p.Borrow(context.Background(), []string{"F"}, false, nil)
+			if _, isFull := err.(*PoolFull); !isFull {
+				t.Fatalf("Expected pool full but was %T: %v", err, err)
+			}
+			assertFalse(t, p.CircuitOpen("F"))
+		}
+	})
+
+	ot.Run("Dead connections count as failures", func(t *testing.T) {
+		p := New(2, time.Hour, connect, logger, "poolid", event.Void{}, nil, 2, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		c1, err := p.Borrow(context.Background(), []string{"C"}, false, nil)
+		assertConnection(t, c1, err)
+		c2, err := p.Borrow(context.Background(), []string{"C"}, false, nil)
+		assertConnection(t, c2, err)
+		c1.(*testutil.ConnFake).Alive = false
+		p.Return(c1)
+		assertFalse(t, p.CircuitOpen("C"))
+		c2.(*testutil.ConnFake).Alive = false
+		p.Return(c2)
+		assertTrue(t, p.CircuitOpen("C"))
+
+		// Other servers should still be used
+		c, err := p.Borrow(context.Background(), []string{"C", "D"}, false, nil)
+		assertConnection(t, c, err)
+		if c.ServerName() != "D" {
+			t.Errorf("Expected connection to D but was %s", c.ServerName())
+		}
+	})
+
+	ot.Run("Failed work on live connections counts as failures", func(t *testing.T) {
+		p := New(2, time.Hour, connect, logger, "poolid", event.Void{}, nil, 2, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		unavailable := &db.Neo4jError{Code: "Neo.TransientError.General.DatabaseUnavailable"}
+		syntax := &db.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"}
+		// Returning the connection after failed work should not reset the failure count
+		c, err := p.Borrow(context.Background(), []string{"G"}, false, nil)
+		assertConnection(t, c, err)
+		p.Failed(c, unavailable)
+		p.Return(c)
+		assertFalse(t, p.CircuitOpen("G"))
+		// Client errors neither count as failures nor as successes
+		c, err = p.Borrow(context.Background(), []string{"G"}, false, nil)
+		assertConnection(t, c, err)
+		p.Failed(c, syntax)
+		p.Return(c)
+		assertFalse(t, p.CircuitOpen("G"))
+		// Failed work that kills the connection counts once
+		c, err = p.Borrow(context.Background(), []string{"G"}, false, nil)
+		assertConnection(t, c, err)
+		p.Failed(c, unavailable)
+		c.(*testutil.ConnFake).Alive = false
+		p.Return(c)
+		assertTrue(t, p.CircuitOpen("G"))
+	})
+
+	ot.Run("Successful work resets failures", func(t *testing.T) {
+		p := New(2, time.Hour, connect, logger, "poolid", event.Void{}, nil, 2, time.Minute)
+		p.now = func() time.Time { return now }
+		defer p.Close()
+		unavailable := &db.Neo4jError{Code: "Neo.TransientError.General.DatabaseUnavailable"}
+		c, err := p.Borrow(context.Background(), []string{"H"}, false, nil)
+		assertConnection(t, c, err)
+		p.Failed(c, unavailable)
+		p.Return(c)
+		c, err = p.Borrow(context.Background(), []string{"H"}, false, nil)
+		assertConnection(t, c, err)
+		p.Return(c)
+		c, err = p.Borrow(context.Background(), []string{"H"}, false, nil)
+		assertConnection(t, c, err)
+		p.Failed(c, unavailable)
+		p.Return(c)
+		assertFalse(t, p.CircuitOpen("H"))
+	})
+
+	ot.Run("Disabled", func(t *testing.T) {
+		p := New(2, time.Hour, connect, logger, "poolid", event.Void{}, nil, 0, time.Minute)
+		defer p.Close()
+		failing["E"] = true
+		for i := 0; i < 5; i++ {
+			p.Borrow(context.Background(), []string{"E"}, false, nil)
+		}
+		assertFalse(t, p.CircuitOpen("E"))
+	})
+}
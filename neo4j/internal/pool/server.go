@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/loadbalance"
 )
 
 // Represents a server with a number of connections that either is in use (borrowed) or
@@ -33,7 +34,10 @@ import (
 type server struct {
 	idle            list.List
 	busy            list.List
+	idleSince       map[db.Connection]time.Time
 	failedConnectAt time.Time
+	failedConnects  int
+	latency         time.Duration
 	roundRobin      uint32
 }
 
@@ -41,12 +45,16 @@ var sharedRoundRobin uint32
 
 const rememberFailedConnectDuration = 3 * time.Minute
 
+// Weight of the latest response time when calculating latency
+const latencyWeight = 0.2
+
 // Returns a idle connection if any
 func (s *server) getIdle() db.Connection {
 	// Remove from idle list and add to busy list
 	e := s.idle.Front()
 	if e != nil {
 		c := s.idle.Remove(e)
+		delete(s.idleSince, c.(db.Connection))
 		s.busy.PushFront(c)
 		// Update round-robin counter every time we give away a connection and keep track
 		// of our own round-robin index
@@ -58,10 +66,12 @@ func (s *server) getIdle() db.Connection {
 
 func (s *server) notifyFailedConnect(now time.Time) {
 	s.failedConnectAt = now
+	s.failedConnects++
 }
 
 func (s *server) notifySuccesfulConnect() {
 	s.failedConnectAt = time.Time{}
+	s.failedConnects = 0
 }
 
 func (s *server) hasFailedConnect(now time.Time) bool {
@@ -102,8 +112,54 @@ func (s *server) calculatePenalty(now time.Time) uint32 {
 }
 
 // Returns a busy connection, makes it idle
-func (s *server) returnBusy(c db.Connection) {
+func (s *server) returnBusy(c db.Connection, now time.Time) {
+	s.measureResponse(c)
 	s.unregisterBusy(c)
+	s.pushIdle(c, now)
+}
+
+// Hands over a busy connection directly to another borrower
+func (s *server) handOverBusy(c db.Connection) {
+	s.measureResponse(c)
+}
+
+// Updates latency with the time it took the server to respond to the latest query on the
+// connection, if the connection measures it.
+func (s *server) measureResponse(c db.Connection) {
+	timer, ok := c.(db.ResponseTimer)
+	if !ok {
+		return
+	}
+	responseTime := timer.ResponseTime()
+	if responseTime <= 0 {
+		return
+	}
+	if s.latency == 0 {
+		s.latency = responseTime
+		return
+	}
+	s.latency = time.Duration(latencyWeight*float64(responseTime) + (1-latencyWeight)*float64(s.latency))
+}
+
+// Returns statistics used by load balancing strategies
+func (s *server) stats(name string, now time.Time) loadbalance.ServerStats {
+	stats := loadbalance.ServerStats{
+		Server:  name,
+		InUse:   s.busy.Len(),
+		Idle:    s.idle.Len(),
+		Latency: s.latency,
+	}
+	if s.hasFailedConnect(now) {
+		stats.RecentFailures = s.failedConnects
+	}
+	return stats
+}
+
+func (s *server) pushIdle(c db.Connection, now time.Time) {
+	if s.idleSince == nil {
+		s.idleSince = make(map[db.Connection]time.Time)
+	}
+	s.idleSince[c] = now
 	s.idle.PushFront(c)
 }
 
@@ -119,6 +175,11 @@ func (s *server) registerBusy(c db.Connection) {
 	s.busy.PushFront(c)
 }
 
+// Adds a new connection to the idle list
+func (s *server) registerIdle(c db.Connection, now time.Time) {
+	s.pushIdle(c, now)
+}
+
 func (s *server) unregisterBusy(c db.Connection) {
 	found := false
 	for e := s.busy.Front(); e != nil && !found; e = e.Next() {
@@ -135,7 +196,10 @@ func (s *server) size() int {
 	return s.busy.Len() + s.idle.Len()
 }
 
-func (s *server) removeIdleOlderThan(now time.Time, maxAge time.Duration) {
+// Closes and removes idle connections that are older than maxAge, returns the number of
+// removed connections.
+func (s *server) removeIdleOlderThan(now time.Time, maxAge time.Duration) int {
+	num := 0
 	e := s.idle.Front()
 	for e != nil {
 		n := e.Next()
@@ -144,23 +208,74 @@ func (s *server) removeIdleOlderThan(now time.Time, maxAge time.Duration) {
 		age := now.Sub(c.Birthdate())
 		if age >= maxAge {
 			s.idle.Remove(e)
+			delete(s.idleSince, c)
+			go c.Close()
+			num++
+		}
+
+		e = n
+	}
+	return num
+}
+
+// Closes and removes idle connections that expired returns true for, returns the number of
+// removed connections.
+func (s *server) removeIdleExpired(expired func(db.Connection) bool) int {
+	num := 0
+	e := s.idle.Front()
+	for e != nil {
+		n := e.Next()
+		c := e.Value.(db.Connection)
+
+		if expired(c) {
+			s.idle.Remove(e)
+			delete(s.idleSince, c)
+			go c.Close()
+			num++
+		}
+
+		e = n
+	}
+	return num
+}
+
+// Closes and removes idle connections that have been idle for maxIdleTime or longer but
+// keeps the keep most recently used idle connections regardless of idle time. Returns the
+// number of removed connections.
+func (s *server) removeIdleSince(now time.Time, maxIdleTime time.Duration, keep int) int {
+	num := 0
+	e := s.idle.Front()
+	for i := 0; e != nil; i++ {
+		n := e.Next()
+		c := e.Value.(db.Connection)
+
+		if i >= keep && now.Sub(s.idleSince[c]) >= maxIdleTime {
+			s.idle.Remove(e)
+			delete(s.idleSince, c)
 			go c.Close()
+			num++
 		}
 
 		e = n
 	}
+	return num
 }
 
-func closeAndEmptyConnections(l list.List) {
+func closeAndEmptyConnections(l list.List) int {
+	num := 0
 	for e := l.Front(); e != nil; e = e.Next() {
 		c := e.Value.(db.Connection)
 		c.Close()
+		num++
 	}
 	l.Init()
+	return num
 }
 
-func (s *server) closeAll() {
-	closeAndEmptyConnections(s.idle)
+// Closes all connections, returns the number of closed connections.
+func (s *server) closeAll() int {
+	s.idleSince = nil
+	num := closeAndEmptyConnections(s.idle)
 	// Closing the busy connections could mean here that we do close from another thread.
-	closeAndEmptyConnections(s.busy)
+	return num + closeAndEmptyConnections(s.busy)
 }
@@ -25,6 +25,7 @@ import (
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/loadbalance"
 )
 
 func assertTrue(t *testing.T, v bool) {
@@ -87,14 +88,14 @@ func TestServer(ot *testing.T) {
 		s := &server{}
 		c1 := &testutil.ConnFake{}
 		s.registerBusy(c1)
-		s.returnBusy(c1)
+		s.returnBusy(c1, time.Time{})
 
 		c2 := s.getIdle()
 		assertConnection(t, c2)
 		c3 := s.getIdle()
 		assertNilConnection(t, c3)
 
-		s.returnBusy(c2)
+		s.returnBusy(c2, time.Time{})
 		c3 = s.getIdle()
 		assertConnection(t, c3)
 	})
@@ -108,7 +109,7 @@ func TestServer(ot *testing.T) {
 			c := &testutil.ConnFake{Birth: now}
 			conns[i] = c
 			s.registerBusy(c)
-			s.returnBusy(c)
+			s.returnBusy(c, time.Time{})
 		}
 
 		// Let the conn in the middle be too old
@@ -125,8 +126,8 @@ func TestServer(ot *testing.T) {
 		assertNilConnection(t, b3)
 
 		// Return the connections and let all of them be too old
-		s.returnBusy(b1)
-		s.returnBusy(b2)
+		s.returnBusy(b1, time.Time{})
+		s.returnBusy(b2, time.Time{})
 		conns[0].Birth = now.Add(-20 * time.Second)
 		conns[2].Birth = now.Add(-20 * time.Second)
 		s.removeIdleOlderThan(now, 10*time.Second)
@@ -161,13 +162,13 @@ func TestServerPenalty(t *testing.T) {
 	// Return the busy connection to srv1
 	// Now srv2 should have higher penalty than srv1 since using srv2 would require a new
 	// connection.
-	srv1.returnBusy(c11)
+	srv1.returnBusy(c11, time.Time{})
 	assertGt(srv2, srv1, now)
 
 	// Add an idle connection to srv2 to make both servers have one idle connection each.
 	c21 := &testutil.ConnFake{Id: 21}
 	srv2.registerBusy(c21)
-	srv2.returnBusy(c21)
+	srv2.returnBusy(c21, time.Time{})
 
 	// At this point round-robin should kick in to even out what server to use, since
 	// srv2 was last in use, srv1 should have lower penalty at this point.
@@ -175,16 +176,16 @@ func TestServerPenalty(t *testing.T) {
 
 	// Get the connection from srv1 and return it, now srv1 should have higher penalty.
 	srv1.getIdle()
-	srv1.returnBusy(c11)
+	srv1.returnBusy(c11, time.Time{})
 	assertGt(srv1, srv2, now)
 
 	// Add one more connection each to the servers
 	c12 := &testutil.ConnFake{Id: 12}
 	srv1.registerBusy(c12)
-	srv1.returnBusy(c12)
+	srv1.returnBusy(c12, time.Time{})
 	c22 := &testutil.ConnFake{Id: 22}
 	srv2.registerBusy(c22)
-	srv2.returnBusy(c22)
+	srv2.returnBusy(c22, time.Time{})
 
 	// Both servers have two idle connections, srv2 was last used so it should have higher penalty.
 	assertGt(srv2, srv1, now)
@@ -198,10 +199,10 @@ func TestServerPenalty(t *testing.T) {
 	assertGt(srv1, srv2, now)
 	// Return the connections
 	srv2.getIdle()
-	srv2.returnBusy(c21)
-	srv2.returnBusy(c22)
-	srv1.returnBusy(c11)
-	srv1.returnBusy(c12)
+	srv2.returnBusy(c21, time.Time{})
+	srv2.returnBusy(c22, time.Time{})
+	srv1.returnBusy(c11, time.Time{})
+	srv1.returnBusy(c12, time.Time{})
 	// Everything returned, srv2 should have higher penalty since it was last used
 	assertGt(srv2, srv1, now)
 
@@ -225,3 +226,60 @@ func TestServerPenalty(t *testing.T) {
 	srv1.notifySuccesfulConnect()
 	assertGt(srv2, srv1, now)
 }
+
+// Connection that reports the time the server took to respond
+type timedConnFake struct {
+	testutil.ConnFake
+	responseTime time.Duration
+}
+
+func (c *timedConnFake) ResponseTime() time.Duration {
+	t := c.responseTime
+	c.responseTime = 0
+	return t
+}
+
+func TestServerStats(t *testing.T) {
+	now := time.Now()
+	srv := &server{}
+	c1 := &timedConnFake{ConnFake: testutil.ConnFake{Id: 1}, responseTime: 100 * time.Millisecond}
+	c2 := &timedConnFake{ConnFake: testutil.ConnFake{Id: 2}, responseTime: 600 * time.Millisecond}
+
+	srv.registerBusy(c1)
+	srv.registerBusy(c2)
+	// Time in use should not matter, only the response time
+	srv.returnBusy(c1, now.Add(time.Hour))
+	stats := srv.stats("srv", now)
+	expected := loadbalance.ServerStats{Server: "srv", InUse: 1, Idle: 1, Latency: 100 * time.Millisecond}
+	if stats != expected {
+		t.Errorf("Expected stats %+v but was %+v", expected, stats)
+	}
+
+	// Latency should be weighted towards the previous value
+	srv.returnBusy(c2, now)
+	stats = srv.stats("srv", now)
+	if stats.Latency != 200*time.Millisecond {
+		t.Errorf("Expected latency 200ms but was %s", stats.Latency)
+	}
+
+	// Connections that haven't run a query since the last measurement are not counted
+	srv.getIdle()
+	srv.returnBusy(c2, now)
+	if stats = srv.stats("srv", now); stats.Latency != 200*time.Millisecond {
+		t.Errorf("Expected latency to stay at 200ms but was %s", stats.Latency)
+	}
+
+	// Failures should be counted until forgotten
+	srv.notifyFailedConnect(now)
+	srv.notifyFailedConnect(now)
+	if stats = srv.stats("srv", now); stats.RecentFailures != 2 {
+		t.Errorf("Expected 2 recent failures but was %d", stats.RecentFailures)
+	}
+	if stats = srv.stats("srv", now.Add(3*time.Hour)); stats.RecentFailures != 0 {
+		t.Errorf("Expected failures to be forgotten but was %d", stats.RecentFailures)
+	}
+	srv.notifySuccesfulConnect()
+	if stats = srv.stats("srv", now); stats.RecentFailures != 0 {
+		t.Errorf("Expected failures to be cleared but was %d", stats.RecentFailures)
+	}
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/retrypolicy"
 )
 
 type Router interface {
@@ -56,23 +57,33 @@ type State struct {
 	MaxDeadConnections      int
 	Router                  Router
 	DatabaseName            string
-
-	start      time.Time
-	cause      string
-	deadErrors int
-	skipSleep  bool
+	// When set, the policy decides whether to retry and for how long to sleep instead of
+	// MaxTransactionRetryTime and Throttle. Retries are still stopped when more than
+	// MaxDeadConnections connections have been lost.
+	Policy retrypolicy.Policy
+	// When set, errors that it reports as expired auth tokens are retried since a new token will
+	// be used on the next attempt.
+	TokenExpired func(err error) bool
+
+	start       time.Time
+	cause       string
+	deadErrors  int
+	skipSleep   bool
+	attempts    int
+	policyDelay time.Duration
 }
 
 func (s *State) OnFailure(conn db.Connection, err error, isCommitting bool) {
 	s.LastErr = err
 	s.cause = ""
 	s.skipSleep = false
+	s.attempts++
 
 	// Check timeout
 	if s.start.IsZero() {
 		s.start = s.Now()
 	}
-	if s.Now().Sub(s.start) > s.MaxTransactionRetryTime {
+	if s.Policy == nil && s.Now().Sub(s.start) > s.MaxTransactionRetryTime {
 		s.stop = true
 		s.cause = "Timeout"
 		return
@@ -81,48 +92,87 @@ func (s *State) OnFailure(conn db.Connection, err error, isCommitting bool) {
 	// Reset after determined to evaluate this error
 	s.LastErrWasRetryable = false
 
+	if !s.classify(conn, err, isCommitting) {
+		s.stop = true
+		return
+	}
+
+	if s.cause == retrypolicy.CauseConnectionLost {
+		s.deadErrors += 1
+		if s.deadErrors > s.MaxDeadConnections {
+			s.stop = true
+			return
+		}
+	}
+
+	if s.Policy != nil {
+		retry, delay := s.Policy.Decide(retrypolicy.Attempt{
+			Number:    s.attempts,
+			Elapsed:   s.Now().Sub(s.start),
+			Err:       err,
+			Cause:     s.cause,
+			Retryable: s.LastErrWasRetryable,
+		})
+		s.stop = !retry
+		// The policy can decide to retry errors that are not retryable by default. When it
+		// decides not to retry the first attempt, the error is returned as is.
+		s.LastErrWasRetryable = retry || (s.LastErrWasRetryable && s.attempts > 1)
+		s.policyDelay = delay
+		return
+	}
+
+	if s.cause == retrypolicy.CauseConnectionLost {
+		s.skipSleep = true
+	}
+	s.stop = !s.LastErrWasRetryable
+}
+
+// Classifies the error and sets the cause when it is retryable. Returns false when it is not
+// safe to retry regardless of the error.
+func (s *State) classify(conn db.Connection, err error, isCommitting bool) bool {
 	// Failed to connect
 	if conn == nil {
 		s.LastErrWasRetryable = true
-		s.cause = "No available connection"
-		return
+		s.cause = retrypolicy.CauseNoConnection
+		return true
 	}
 
 	// Check if the connection died, if it died during commit it is not safe to retry.
 	if !conn.IsAlive() {
 		if isCommitting {
-			s.stop = true
 			// The error is most probably io.EOF so enrich the error
 			// to make this error more recognizable.
 			s.LastErr = &CommitFailedDeadError{inner: s.LastErr}
-			return
+			return false
 		}
 
-		s.deadErrors += 1
-		s.stop = s.deadErrors > s.MaxDeadConnections
 		s.LastErrWasRetryable = true
-		s.cause = "Connection lost"
-		s.skipSleep = true
-		return
+		s.cause = retrypolicy.CauseConnectionLost
+		return true
+	}
+
+	if s.TokenExpired != nil && s.TokenExpired(err) {
+		s.cause = retrypolicy.CauseTokenExpired
+		s.LastErrWasRetryable = true
+		return true
 	}
 
 	if dbErr, isDbErr := err.(*db.Neo4jError); isDbErr {
 		if dbErr.IsRetriableCluster() {
 			// Force routing tables to be updated before trying again
 			s.Router.Invalidate(s.DatabaseName)
-			s.cause = "Cluster error"
+			s.cause = retrypolicy.CauseClusterError
 			s.LastErrWasRetryable = true
-			return
+			return true
 		}
 
 		if dbErr.IsRetriableTransient() {
-			s.cause = "Transient error"
+			s.cause = retrypolicy.CauseTransientError
 			s.LastErrWasRetryable = true
-			return
+			return true
 		}
 	}
-
-	s.stop = true
+	return true
 }
 
 func (s *State) Continue() bool {
@@ -139,7 +189,13 @@ func (s *State) Continue() bool {
 
 	// Retry after optional sleep
 	if !s.stop {
-		if s.skipSleep {
+		if s.Policy != nil {
+			s.Log.Debugf(s.LogName, s.LogId,
+				"Retrying transaction (%s): %s [after %s]", s.cause, s.LastErr, s.policyDelay)
+			if s.policyDelay > 0 {
+				s.Sleep(s.policyDelay)
+			}
+		} else if s.skipSleep {
 			s.Log.Debugf(s.LogName, s.LogId, "Retrying transaction (%s): %s", s.cause, s.LastErr)
 		} else {
 			s.Throttle = s.Throttle.next()
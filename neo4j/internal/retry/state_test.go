@@ -30,6 +30,7 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/pool"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/retrypolicy"
 )
 
 type TStateInvocation struct {
@@ -172,3 +173,112 @@ func TestState(tt *testing.T) {
 		})
 	}
 }
+
+func TestStateWithPolicy(t *testing.T) {
+	var (
+		baseTime     = time.Now()
+		now          = baseTime
+		dbTransient  = &db.Neo4jError{Code: "Neo.TransientError.Some.Some"}
+		attempts     []retrypolicy.Attempt
+		sleeps       []time.Duration
+		retryDecided = true
+	)
+	state := State{
+		Now:     func() time.Time { return now },
+		Log:     &log.Console{Errors: true, Debugs: true},
+		LogName: "TEST",
+		LogId:   "State",
+		Sleep:   func(d time.Duration) { sleeps = append(sleeps, d) },
+		Router:  &testutil.RouterFake{},
+		Policy: retrypolicy.PolicyFunc(func(a retrypolicy.Attempt) (bool, time.Duration) {
+			attempts = append(attempts, a)
+			return retryDecided, time.Duration(a.Number) * time.Second
+		}),
+	}
+
+	state.Continue()
+	state.OnFailure(nil, errors.New("connect error"), false)
+	if !state.Continue() {
+		t.Fatal("Should continue when policy decides to retry")
+	}
+	now = baseTime.Add(time.Hour)
+	userErr := errors.New("user error")
+	state.OnFailure(&testutil.ConnFake{Alive: true}, userErr, false)
+	if !state.Continue() || !state.LastErrWasRetryable {
+		t.Fatal("Should continue and consider error retryable when policy decides to retry")
+	}
+	retryDecided = false
+	state.OnFailure(&testutil.ConnFake{Alive: true}, dbTransient, false)
+	if state.Continue() {
+		t.Fatal("Should not continue when policy decides not to retry")
+	}
+	if !state.LastErrWasRetryable {
+		t.Error("Transient error should still be considered retryable")
+	}
+
+	expected := []retrypolicy.Attempt{
+		{Number: 1, Err: attempts[0].Err, Cause: retrypolicy.CauseNoConnection, Retryable: true},
+		{Number: 2, Elapsed: time.Hour, Err: userErr},
+		{Number: 3, Elapsed: time.Hour, Err: dbTransient, Cause: retrypolicy.CauseTransientError, Retryable: true},
+	}
+	if !reflect.DeepEqual(attempts, expected) {
+		t.Errorf("Expected attempts %+v but was %+v", expected, attempts)
+	}
+	if !reflect.DeepEqual(sleeps, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("Unexpected sleeps %v", sleeps)
+	}
+
+	t.Run("Declined first attempt is not retryable", func(t *testing.T) {
+		state := State{
+			Now:    time.Now,
+			Router: &testutil.RouterFake{},
+			Policy: retrypolicy.NoRetry(),
+		}
+		state.OnFailure(&testutil.ConnFake{Alive: true}, dbTransient, false)
+		if state.Continue() {
+			t.Fatal("Should not continue when policy decides not to retry")
+		}
+		if state.LastErrWasRetryable {
+			t.Error("Error should be returned as is when nothing has been retried")
+		}
+	})
+
+	t.Run("Lost connections limit retries", func(t *testing.T) {
+		state := State{
+			Now:                time.Now,
+			Log:                &log.Void{},
+			Router:             &testutil.RouterFake{},
+			MaxDeadConnections: 1,
+			Policy: retrypolicy.PolicyFunc(func(retrypolicy.Attempt) (bool, time.Duration) {
+				return true, 0
+			}),
+		}
+		state.OnFailure(&testutil.ConnFake{Alive: false}, io.EOF, false)
+		if !state.Continue() {
+			t.Fatal("Should continue when policy decides to retry")
+		}
+		state.OnFailure(&testutil.ConnFake{Alive: false}, io.EOF, false)
+		if state.Continue() {
+			t.Error("Should not continue when too many connections have been lost")
+		}
+	})
+
+	t.Run("Fail during commit is never retried", func(t *testing.T) {
+		called := false
+		state := State{
+			Now:    time.Now,
+			Router: &testutil.RouterFake{},
+			Policy: retrypolicy.PolicyFunc(func(retrypolicy.Attempt) (bool, time.Duration) {
+				called = true
+				return true, 0
+			}),
+		}
+		state.OnFailure(&testutil.ConnFake{Alive: false}, io.EOF, true)
+		if called {
+			t.Error("Policy should not be asked")
+		}
+		if _, isCommitFailed := state.LastErr.(*CommitFailedDeadError); !isCommitFailed {
+			t.Errorf("Unexpected error %v", state.LastErr)
+		}
+	})
+}
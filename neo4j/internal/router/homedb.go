@@ -0,0 +1,83 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import (
+	"time"
+)
+
+// Identifies the user that the home database was resolved for
+type homeDbKey struct {
+	user             string
+	impersonatedUser string
+}
+
+type homeDb struct {
+	database string
+	dueUnix  int64
+}
+
+// Caches resolved home databases for users. Not thread safe, the router guards access with its
+// own mutex.
+type homeDbCache struct {
+	ttl     time.Duration
+	entries map[homeDbKey]homeDb
+}
+
+func newHomeDbCache(ttl time.Duration) *homeDbCache {
+	return &homeDbCache{ttl: ttl, entries: make(map[homeDbKey]homeDb)}
+}
+
+func (c *homeDbCache) get(key homeDbKey, now time.Time) (string, bool) {
+	entry, exists := c.entries[key]
+	if !exists {
+		return "", false
+	}
+	if now.Unix() >= entry.dueUnix {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.database, true
+}
+
+func (c *homeDbCache) put(key homeDbKey, database string, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.entries[key] = homeDb{database: database, dueUnix: now.Add(c.ttl).Unix()}
+}
+
+// Removes all users that has the database as home database.
+func (c *homeDbCache) invalidate(database string) {
+	for key, entry := range c.entries {
+		if entry.database == database {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Removes all expired entries.
+func (c *homeDbCache) cleanUp(now time.Time) {
+	for key, entry := range c.entries {
+		if now.Unix() >= entry.dueUnix {
+			delete(c.entries, key)
+		}
+	}
+}
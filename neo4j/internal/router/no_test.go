@@ -30,6 +30,7 @@ type poolFake struct {
 	borrow   func(names []string, cancel context.CancelFunc, logger log.BoltLogger) (db.Connection, error)
 	returned []db.Connection
 	cancel   context.CancelFunc
+	open     map[string]bool
 }
 
 func (p *poolFake) Borrow(ctx context.Context, servers []string, wait bool, logger log.BoltLogger) (db.Connection, error) {
@@ -39,3 +40,7 @@ func (p *poolFake) Borrow(ctx context.Context, servers []string, wait bool, logg
 func (p *poolFake) Return(c db.Connection) {
 	p.returned = append(p.returned, c)
 }
+
+func (p *poolFake) CircuitOpen(server string) bool {
+	return p.open[server]
+}
@@ -22,10 +22,12 @@ package router
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
 
@@ -35,6 +37,19 @@ const missingReaderRetries = 100
 type databaseRouter struct {
 	dueUnix int64
 	table   *db.RoutingTable
+	// Set when the table has been used since it was read
+	used bool
+}
+
+// Represents an ongoing read of a routing table that other callers can wait for
+type tableRead struct {
+	done chan struct{}
+	// Bookmarks used for the read, only callers with bookmarks covered by these can wait for it
+	bookmarks []string
+	table     *db.RoutingTable
+	err       error
+	// Set when the read failed because the context of the caller that made it was done
+	cancelled bool
 }
 
 // Thread safe
@@ -43,30 +58,47 @@ type Router struct {
 	pool          Pool
 	dbRouters     map[string]*databaseRouter
 	dbRoutersMut  sync.Mutex
+	reads         map[string]*tableRead
+	gracePeriod   time.Duration
+	homeDbs       *homeDbCache
+	getUser       func() string
 	now           func() time.Time
 	sleep         func(time.Duration)
 	rootRouter    string
 	getRouters    func() []string
 	log           log.Logger
 	logId         string
+	listener      event.Listener
 }
 
 type Pool interface {
 	Borrow(ctx context.Context, servers []string, wait bool, boltLogger log.BoltLogger) (db.Connection, error)
 	Return(c db.Connection)
+	// Returns true if the server should not be used due to too many failures.
+	CircuitOpen(server string) bool
 }
 
-func New(rootRouter string, getRouters func() []string, routerContext map[string]string, pool Pool, logger log.Logger, logId string) *Router {
+// New creates a new router. When reading a new routing table fails, an expired routing table is
+// still used until it has been expired for longer than the grace period.
+// Resolved home databases are cached per user for homeDbTTL, the current user is retrieved by
+// calling getUser. Zero or negative homeDbTTL disables the cache, so does a nil getUser or an
+// empty user since different identities can't be told apart then.
+func New(rootRouter string, getRouters func() []string, routerContext map[string]string, pool Pool, logger log.Logger, logId string, listener event.Listener, gracePeriod, homeDbTTL time.Duration, getUser func() string) *Router {
 	r := &Router{
 		rootRouter:    rootRouter,
 		getRouters:    getRouters,
 		routerContext: routerContext,
 		pool:          pool,
 		dbRouters:     make(map[string]*databaseRouter),
+		reads:         make(map[string]*tableRead),
+		gracePeriod:   gracePeriod,
+		homeDbs:       newHomeDbCache(homeDbTTL),
+		getUser:       getUser,
 		now:           time.Now,
 		sleep:         time.Sleep,
 		log:           logger,
 		logId:         logId,
+		listener:      listener,
 	}
 	r.log.Infof(log.Router, r.logId, "Created {context: %v}", routerContext)
 	return r
@@ -91,10 +123,11 @@ func (r *Router) readTable(ctx context.Context, dbRouter *databaseRouter, bookma
 		table, err = readTable(ctx, r.pool, []string{r.rootRouter}, r.routerContext, bookmarks, database, impersonatedUser, boltLogger)
 	}
 
-	// Use hook to retrieve possibly different set of routers and retry
+	// Use hook to retrieve possibly different set of routers and retry, the hook is called every
+	// time to let it re-resolve the routers since the known ones might have moved.
 	if table == nil && r.getRouters != nil {
 		routers := r.getRouters()
-		r.log.Infof(log.Router, r.logId, "Reading routing table for '%s' from custom routers: %v", routers)
+		r.log.Infof(log.Router, r.logId, "Reading routing table for '%s' from custom routers: %v", database, routers)
 		table, err = readTable(ctx, r.pool, routers, r.routerContext, bookmarks, database, impersonatedUser, boltLogger)
 	}
 
@@ -116,26 +149,196 @@ func (r *Router) getOrReadTable(ctx context.Context, bookmarks []string, databas
 	now := r.now()
 
 	r.dbRoutersMut.Lock()
-	defer r.dbRoutersMut.Unlock()
-
 	dbRouter := r.dbRouters[database]
 	if dbRouter != nil && now.Unix() < dbRouter.dueUnix {
+		dbRouter.used = true
+		r.dbRoutersMut.Unlock()
 		return dbRouter.table, nil
 	}
+	r.dbRoutersMut.Unlock()
+
+	return r.refreshTable(ctx, bookmarks, database, boltLogger)
+}
 
+// Reads a new routing table for the database unless there already is an ongoing read for the
+// same database with bookmarks that cover the bookmarks of the caller, in that case the result
+// of that read is waited for instead. When the ongoing read fails because the context of the
+// caller that made it is done, the table is read again under the context of the waiting caller.
+func (r *Router) refreshTable(ctx context.Context, bookmarks []string, database string, boltLogger log.BoltLogger) (*db.RoutingTable, error) {
+	for {
+		r.dbRoutersMut.Lock()
+		read := r.reads[database]
+		if read != nil && coversBookmarks(read.bookmarks, bookmarks) {
+			r.dbRoutersMut.Unlock()
+			select {
+			case <-read.done:
+			case <-ctx.Done():
+				return nil, wrapError(r.rootRouter, ctx.Err())
+			}
+			if read.cancelled {
+				continue
+			}
+			return read.table, read.err
+		}
+		if read == nil {
+			read = &tableRead{done: make(chan struct{}), bookmarks: bookmarks}
+			r.reads[database] = read
+		} else {
+			// The ongoing read might not be causally consistent with the bookmarks of this
+			// caller, read on our own without sharing it.
+			read = nil
+		}
+		dbRouter := r.dbRouters[database]
+		r.dbRoutersMut.Unlock()
+
+		return r.readAndStoreTable(ctx, read, dbRouter, bookmarks, database, boltLogger)
+	}
+}
+
+// Reads and stores the routing table, passes the result on to the callers waiting for the read
+// when it is shared.
+func (r *Router) readAndStoreTable(ctx context.Context, read *tableRead, dbRouter *databaseRouter, bookmarks []string, database string, boltLogger log.BoltLogger) (*db.RoutingTable, error) {
+	// Read without holding the lock to avoid blocking usage of other databases
 	table, err := r.readTable(ctx, dbRouter, bookmarks, database, "", boltLogger)
-	if err != nil {
-		return nil, err
+
+	r.dbRoutersMut.Lock()
+	defer r.dbRoutersMut.Unlock()
+	now := r.now()
+	if err == nil {
+		// Store the routing table
+		r.storeTable(database, table, now)
+		r.log.Debugf(log.Router, r.logId, "New routing table for '%s', TTL %d", database, table.TimeToLive)
+	} else if stale := r.staleTable(database, now); stale != nil {
+		r.log.Warnf(log.Router, r.logId, "Using expired routing table for '%s' after failed read: %s", database, err)
+		table, err = stale, nil
+	}
+	if read != nil {
+		read.table, read.err = table, err
+		read.cancelled = err != nil && ctx.Err() != nil
+		delete(r.reads, database)
+		close(read.done)
 	}
+	return table, err
+}
 
-	// Store the routing table
+// Returns true if all bookmarks are among the covering bookmarks.
+func coversBookmarks(covering, bookmarks []string) bool {
+	for _, b := range bookmarks {
+		found := false
+		for _, c := range covering {
+			if b == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the expired routing table for the database if it is still within the grace period.
+// Invalidated tables are never returned. Must be called with dbRoutersMut held.
+func (r *Router) staleTable(database string, now time.Time) *db.RoutingTable {
+	dbRouter := r.dbRouters[database]
+	if dbRouter == nil || dbRouter.dueUnix == 0 || r.gracePeriod <= 0 {
+		return nil
+	}
+	if now.Before(time.Unix(dbRouter.dueUnix, 0).Add(r.gracePeriod)) {
+		return dbRouter.table
+	}
+	return nil
+}
+
+// RefreshExpiring reads new routing tables for databases with tables that expire within the
+// specified duration and that have been used since they were read. This avoids that requests
+// need to wait for the routing table to be read once the table has expired.
+func (r *Router) RefreshExpiring(ctx context.Context, within time.Duration) {
+	due := r.now().Add(within).Unix()
+	r.dbRoutersMut.Lock()
+	var databases []string
+	for database, dbRouter := range r.dbRouters {
+		if dbRouter.used && dbRouter.dueUnix > 0 && dbRouter.dueUnix <= due && r.reads[database] == nil {
+			databases = append(databases, database)
+		}
+	}
+	r.dbRoutersMut.Unlock()
+
+	for _, database := range databases {
+		r.log.Debugf(log.Router, r.logId, "Refreshing routing table for '%s' before it expires", database)
+		if _, err := r.refreshTable(ctx, nil, database, nil); err != nil {
+			r.log.Warnf(log.Router, r.logId, "Failed to refresh routing table for '%s': %s", database, err)
+		}
+	}
+}
+
+// Stores the routing table for the database and notifies the listener about it.
+// Must be called with dbRoutersMut held.
+func (r *Router) storeTable(database string, table *db.RoutingTable, now time.Time) {
+	var previousWriters []string
+	if previous := r.dbRouters[database]; previous != nil {
+		previousWriters = previous.table.Writers
+		if !sameTable(previous.table, table) {
+			// Home database might have moved
+			r.homeDbs.invalidate(database)
+		}
+	}
 	r.dbRouters[database] = &databaseRouter{
 		table:   table,
 		dueUnix: now.Add(time.Duration(table.TimeToLive) * time.Second).Unix(),
 	}
-	r.log.Debugf(log.Router, r.logId, "New routing table for '%s', TTL %d", database, table.TimeToLive)
+	r.listener.OnEvent(event.RoutingTableUpdated{
+		Database:        database,
+		Routers:         table.Routers,
+		Readers:         table.Readers,
+		Writers:         table.Writers,
+		PreviousWriters: previousWriters,
+		WritersChanged:  !sameServers(previousWriters, table.Writers),
+	})
+}
+
+// Returns true if both tables contains the same servers.
+func sameTable(x, y *db.RoutingTable) bool {
+	return sameServers(x.Routers, y.Routers) && sameServers(x.Readers, y.Readers) && sameServers(x.Writers, y.Writers)
+}
+
+// Returns true if both lists contains the same servers regardless of order.
+func sameServers(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	set := make(map[string]bool, len(x))
+	for _, s := range x {
+		set[s] = true
+	}
+	for _, s := range y {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
 
-	return table, nil
+// Table returns a copy of the routing table for the database, reads a new routing table if there
+// is no known table or if the known table has expired. The time when the table expires is
+// returned as well.
+func (r *Router) Table(ctx context.Context, bookmarks []string, database string, boltLogger log.BoltLogger) (*db.RoutingTable, time.Time, error) {
+	table, err := r.getOrReadTable(ctx, bookmarks, database, boltLogger)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	r.dbRoutersMut.Lock()
+	defer r.dbRoutersMut.Unlock()
+	var expires time.Time
+	if dbRouter := r.dbRouters[database]; dbRouter != nil && dbRouter.table == table {
+		expires = time.Unix(dbRouter.dueUnix, 0)
+	}
+	copied := *table
+	copied.Routers = append([]string(nil), table.Routers...)
+	copied.Readers = append([]string(nil), table.Readers...)
+	copied.Writers = append([]string(nil), table.Writers...)
+	return &copied, expires, nil
 }
 
 func (r *Router) Readers(ctx context.Context, bookmarks []string, database string, boltLogger log.BoltLogger) ([]string, error) {
@@ -163,7 +366,7 @@ func (r *Router) Readers(ctx context.Context, bookmarks []string, database strin
 		return nil, wrapError(r.rootRouter, errors.New("No readers"))
 	}
 
-	return table.Readers, nil
+	return r.skipOpen(table.Readers), nil
 }
 
 func (r *Router) Writers(ctx context.Context, bookmarks []string, database string, boltLogger log.BoltLogger) ([]string, error) {
@@ -191,10 +394,45 @@ func (r *Router) Writers(ctx context.Context, bookmarks []string, database strin
 		return nil, wrapError(r.rootRouter, errors.New("No writers"))
 	}
 
-	return table.Writers, nil
+	return r.skipOpen(table.Writers), nil
+}
+
+// Removes servers with an open circuit breaker. If all servers are open they are all returned
+// to let the pool decide when to probe them.
+func (r *Router) skipOpen(servers []string) []string {
+	available := make([]string, 0, len(servers))
+	for _, s := range servers {
+		if !r.pool.CircuitOpen(s) {
+			available = append(available, s)
+		}
+	}
+	if len(available) == 0 {
+		return servers
+	}
+	if len(available) < len(servers) {
+		r.log.Debugf(log.Router, r.logId, "Skipping servers with open circuit breaker, using %v of %v", available, servers)
+	}
+	return available
 }
 
 func (r *Router) GetNameOfDefaultDatabase(ctx context.Context, bookmarks []string, user string, boltLogger log.BoltLogger) (string, error) {
+	key := homeDbKey{impersonatedUser: user}
+	if r.getUser != nil {
+		key.user = r.getUser()
+	}
+	// Without a known user, like for bearer tokens, the entry could be shared by different users
+	useCache := key.user != ""
+	r.dbRoutersMut.Lock()
+	database, cached := "", false
+	if useCache {
+		database, cached = r.homeDbs.get(key, r.now())
+	}
+	r.dbRoutersMut.Unlock()
+	if cached {
+		r.log.Debugf(log.Router, r.logId, "Using cached default database for impersonated user '%s': '%s'", user, database)
+		return database, nil
+	}
+
 	table, err := r.readTable(ctx, nil, bookmarks, db.DefaultDatabase, user, boltLogger)
 	if err != nil {
 		return "", err
@@ -203,15 +441,50 @@ func (r *Router) GetNameOfDefaultDatabase(ctx context.Context, bookmarks []strin
 	now := r.now()
 	r.dbRoutersMut.Lock()
 	defer r.dbRoutersMut.Unlock()
-	r.dbRouters[table.DatabaseName] = &databaseRouter{
-		table:   table,
-		dueUnix: now.Add(time.Duration(table.TimeToLive) * time.Second).Unix(),
+	r.storeTable(table.DatabaseName, table, now)
+	if useCache {
+		r.homeDbs.put(key, table.DatabaseName, now)
 	}
 	r.log.Debugf(log.Router, r.logId, "New routing table when retrieving default database for impersonated user: '%s', TTL %d", table.DatabaseName, table.TimeToLive)
 
 	return table.DatabaseName, err
 }
 
+// KnownServers returns the routers, readers and writers of all currently known routing tables.
+func (r *Router) KnownServers() []string {
+	return r.knownServers(true)
+}
+
+// KnownReadersAndWriters returns the readers and writers of all currently known routing tables.
+func (r *Router) KnownReadersAndWriters() []string {
+	return r.knownServers(false)
+}
+
+func (r *Router) knownServers(includeRouters bool) []string {
+	r.dbRoutersMut.Lock()
+	defer r.dbRoutersMut.Unlock()
+	set := make(map[string]bool)
+	for _, dbRouter := range r.dbRouters {
+		if includeRouters {
+			for _, s := range dbRouter.table.Routers {
+				set[s] = true
+			}
+		}
+		for _, s := range dbRouter.table.Readers {
+			set[s] = true
+		}
+		for _, s := range dbRouter.table.Writers {
+			set[s] = true
+		}
+	}
+	servers := make([]string, 0, len(set))
+	for s := range set {
+		servers = append(servers, s)
+	}
+	sort.Strings(servers)
+	return servers
+}
+
 func (r *Router) Context() map[string]string {
 	return r.routerContext
 }
@@ -226,11 +499,14 @@ func (r *Router) Invalidate(database string) {
 	if dbRouter != nil {
 		dbRouter.dueUnix = 0
 	}
+	r.homeDbs.invalidate(database)
+	r.listener.OnEvent(event.RoutingTableInvalidated{Database: database})
 }
 
 func (r *Router) CleanUp() {
 	r.log.Debugf(log.Router, r.logId, "Cleaning up")
-	now := r.now().Unix()
+	// Keep expired tables around during the grace period
+	now := r.now().Add(-r.gracePeriod).Unix()
 	r.dbRoutersMut.Lock()
 	defer r.dbRoutersMut.Unlock()
 
@@ -239,4 +515,5 @@ func (r *Router) CleanUp() {
 			delete(r.dbRouters, dbName)
 		}
 	}
+	r.homeDbs.cleanUp(r.now())
 }
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
@@ -50,7 +51,7 @@ func TestMultithreading(t *testing.T) {
 		},
 	}
 	n := time.Now()
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	mut := sync.Mutex{}
 	router.now = func() time.Time {
 		// Need to lock here to make race detector happy
@@ -107,7 +108,7 @@ func TestRespectsTimeToLiveAndInvalidate(t *testing.T) {
 	}
 	nzero := time.Now()
 	n := nzero
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.now = func() time.Time {
 		return n
 	}
@@ -153,7 +154,7 @@ func TestUsesRootRouterWhenPreviousRoutersFails(t *testing.T) {
 	}
 	nzero := time.Now()
 	n := nzero
-	router := New("rootRouter", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("rootRouter", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.now = func() time.Time {
 		return n
 	}
@@ -215,7 +216,7 @@ func TestUseGetRoutersHookWhenInitialRouterFails(t *testing.T) {
 	}
 	rootRouter := "rootRouter"
 	backupRouters := []string{"bup1", "bup2"}
-	router := New(rootRouter, func() []string { return backupRouters }, nil, pool, logger, "routerid")
+	router := New(rootRouter, func() []string { return backupRouters }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	dbName := "dbname"
 
 	// Trigger read of routing table
@@ -229,6 +230,40 @@ func TestUseGetRoutersHookWhenInitialRouterFails(t *testing.T) {
 	}
 }
 
+// Verify that the callback to get backup routers is invoked every time all known routers fail
+// to let it pick up changes to the set of routers.
+func TestGetRoutersHookIsCalledWhenAllRoutersFail(t *testing.T) {
+	resolved := [][]string{{"bup1"}, {"bup2"}}
+	numResolves := 0
+	getRouters := func() []string {
+		routers := resolved[numResolves]
+		numResolves++
+		return routers
+	}
+	tried := []string{}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			tried = append(tried, names...)
+			if names[0] == "bup1" {
+				return &testutil.ConnFake{Table: &db.RoutingTable{TimeToLive: 1, Routers: []string{"rt1"}, Readers: []string{"rd1"}}}, nil
+			}
+			return nil, errors.New("fail")
+		},
+	}
+	router := New("rootRouter", getRouters, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+	dbName := "dbname"
+
+	router.Readers(context.Background(), nil, dbName, nil)
+	router.Invalidate(dbName)
+	router.Readers(context.Background(), nil, dbName, nil)
+
+	expected := []string{"rootRouter", "bup1", "rt1", "rootRouter", "bup2"}
+	if !reflect.DeepEqual(tried, expected) {
+		t.Errorf("Didn't try the expected routers, tried: %#v", tried)
+	}
+	assertNum(t, numResolves, 2, "Should have resolved routers twice")
+}
+
 func TestWritersFailAfterNRetries(t *testing.T) {
 	numfetch := 0
 	tableNoWriters := &db.RoutingTable{TimeToLive: 1, Routers: []string{"rt1", "rt2"}, Readers: []string{"rd1"}}
@@ -240,7 +275,7 @@ func TestWritersFailAfterNRetries(t *testing.T) {
 		},
 	}
 	numsleep := 0
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.sleep = func(time.Duration) {
 		numsleep++
 	}
@@ -277,7 +312,7 @@ func TestWritersRetriesWhenNoWriters(t *testing.T) {
 		},
 	}
 	numsleep := 0
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.sleep = func(time.Duration) {
 		numsleep++
 	}
@@ -315,7 +350,7 @@ func TestReadersRetriesWhenNoReaders(t *testing.T) {
 		},
 	}
 	numsleep := 0
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.sleep = func(time.Duration) {
 		numsleep++
 	}
@@ -348,7 +383,7 @@ func TestCleanUp(t *testing.T) {
 		},
 	}
 	now := time.Now()
-	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid")
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
 	router.now = func() time.Time { return now }
 
 	router.Readers(context.Background(), nil, "db1", nil)
@@ -371,3 +406,400 @@ func TestCleanUp(t *testing.T) {
 		t.Fatal("Should have cleaned up")
 	}
 }
+
+func TestNotifiesRoutingTableEvents(t *testing.T) {
+	writers := []string{"wr1"}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			return &testutil.ConnFake{Table: &db.RoutingTable{TimeToLive: 1, Readers: []string{"rd1"}, Writers: writers}}, nil
+		},
+	}
+	listener := &testutil.ListenerFake{}
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", listener, 0, 0, nil)
+	dbName := "dbname"
+
+	router.Writers(context.Background(), nil, dbName, nil)
+	router.Invalidate(dbName)
+	router.Writers(context.Background(), nil, dbName, nil)
+	router.Invalidate(dbName)
+	writers = []string{"wr2"}
+	router.Writers(context.Background(), nil, dbName, nil)
+
+	expected := []event.Event{
+		event.RoutingTableUpdated{Database: dbName, Readers: []string{"rd1"}, Writers: []string{"wr1"}, WritersChanged: true},
+		event.RoutingTableInvalidated{Database: dbName},
+		event.RoutingTableUpdated{Database: dbName, Readers: []string{"rd1"}, Writers: []string{"wr1"}, PreviousWriters: []string{"wr1"}},
+		event.RoutingTableInvalidated{Database: dbName},
+		event.RoutingTableUpdated{Database: dbName, Readers: []string{"rd1"}, Writers: []string{"wr2"}, PreviousWriters: []string{"wr1"}, WritersChanged: true},
+	}
+	if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v but was %v", expected, events)
+	}
+}
+
+func TestKnownServers(t *testing.T) {
+	tables := map[string]*db.RoutingTable{
+		"db1": {TimeToLive: 1, Routers: []string{"rt1"}, Readers: []string{"rd1", "rd2"}, Writers: []string{"wr1"}},
+		"db2": {TimeToLive: 1, Routers: []string{"rt1"}, Readers: []string{"rd2", "rd3"}, Writers: []string{"wr1"}},
+	}
+	var database string
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			return &testutil.ConnFake{Table: tables[database]}, nil
+		},
+	}
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+
+	if servers := router.KnownServers(); len(servers) != 0 {
+		t.Errorf("Should not know any servers before reading routing tables but knew %v", servers)
+	}
+	for _, database = range []string{"db1", "db2"} {
+		router.Readers(context.Background(), nil, database, nil)
+	}
+	expected := []string{"rd1", "rd2", "rd3", "rt1", "wr1"}
+	if servers := router.KnownServers(); !reflect.DeepEqual(servers, expected) {
+		t.Errorf("Expected known servers %v but was %v", expected, servers)
+	}
+	expected = []string{"rd1", "rd2", "rd3", "wr1"}
+	if servers := router.KnownReadersAndWriters(); !reflect.DeepEqual(servers, expected) {
+		t.Errorf("Expected known readers and writers %v but was %v", expected, servers)
+	}
+}
+
+func TestTable(t *testing.T) {
+	numfetch := 0
+	table := &db.RoutingTable{TimeToLive: 10, DatabaseName: "dbname", Routers: []string{"rt1"}, Readers: []string{"rd1"}, Writers: []string{"wr1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			numfetch++
+			return &testutil.ConnFake{Table: table}, nil
+		},
+	}
+	n := time.Unix(1000, 0)
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+	router.now = func() time.Time { return n }
+
+	copied, expires, err := router.Table(context.Background(), nil, "dbname", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(copied, table) || copied == table {
+		t.Errorf("Expected copy of %+v but was %+v", table, copied)
+	}
+	if !expires.Equal(n.Add(10 * time.Second)) {
+		t.Errorf("Unexpected expiry time %s", expires)
+	}
+
+	// Known table should be returned without reading
+	_, _, err = router.Table(context.Background(), nil, "dbname", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNum(t, numfetch, 1, "Should not have fetched")
+
+	// Invalidated table should be read again
+	router.Invalidate("dbname")
+	_, _, err = router.Table(context.Background(), nil, "dbname", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNum(t, numfetch, 2, "Should have fetched")
+}
+
+func TestConcurrentReadsAreShared(t *testing.T) {
+	numfetch := 0
+	started := make(chan struct{})
+	release := make(chan struct{})
+	table := &db.RoutingTable{TimeToLive: 10, Readers: []string{"rd1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			numfetch++
+			close(started)
+			<-release
+			return &testutil.ConnFake{Table: table}, nil
+		},
+	}
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := router.Readers(context.Background(), nil, "dbname", nil); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		if _, err := router.Readers(context.Background(), nil, "dbname", nil); err != nil {
+			t.Error(err)
+		}
+	}()
+	// Give the second reader a chance to start waiting for the ongoing read
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assertNum(t, numfetch, 1, "Should only have fetched once")
+
+	t.Run("Waiting is cancelled by context", func(t *testing.T) {
+		router.reads["waiting"] = &tableRead{done: make(chan struct{})}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := router.Readers(ctx, nil, "waiting", nil)
+		if err == nil {
+			t.Error("Expected error when context is cancelled")
+		}
+	})
+
+	t.Run("Waits for read with covering bookmarks", func(t *testing.T) {
+		router.reads["covering"] = &tableRead{done: make(chan struct{}), bookmarks: []string{"b1", "b2"}}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := router.Readers(ctx, []string{"b2"}, "covering", nil)
+		if err == nil {
+			t.Error("Expected to wait for the ongoing read until the context is done")
+		}
+	})
+
+	t.Run("Reads on its own when bookmarks are not covered", func(t *testing.T) {
+		numfetch := 0
+		pool := &poolFake{
+			borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+				numfetch++
+				return &testutil.ConnFake{Table: table}, nil
+			},
+		}
+		router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+		router.reads["dbname"] = &tableRead{done: make(chan struct{}), bookmarks: []string{"b1"}}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := router.Readers(ctx, []string{"b1", "b2"}, "dbname", nil); err != nil {
+			t.Error(err)
+		}
+		assertNum(t, numfetch, 1, "Should have fetched on its own")
+	})
+}
+
+func TestConcurrentReadRetriedWhenReaderGivesUp(t *testing.T) {
+	numfetch := 0
+	started := make(chan struct{})
+	release := make(chan struct{})
+	table := &db.RoutingTable{TimeToLive: 10, Readers: []string{"rd1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			numfetch++
+			if numfetch == 1 {
+				close(started)
+				<-release
+				return nil, errors.New("gave up")
+			}
+			return &testutil.ConnFake{Table: table}, nil
+		},
+	}
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := router.Readers(ctx, nil, "dbname", nil); err == nil {
+			t.Error("Expected the cancelled reader to fail")
+		}
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		if _, err := router.Readers(context.Background(), nil, "dbname", nil); err != nil {
+			t.Errorf("Waiting reader should not fail because another reader gave up: %s", err)
+		}
+	}()
+	// Give the second reader a chance to start waiting for the ongoing read
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	close(release)
+	wg.Wait()
+	assertNum(t, numfetch, 2, "Should have fetched again for the waiting reader")
+}
+
+func TestStaleGracePeriod(t *testing.T) {
+	var fail error
+	table := &db.RoutingTable{TimeToLive: 10, Readers: []string{"rd1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			if fail != nil {
+				return nil, fail
+			}
+			return &testutil.ConnFake{Table: table}, nil
+		},
+	}
+	n := time.Unix(1000, 0)
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, time.Minute, 0, nil)
+	router.now = func() time.Time { return n }
+
+	if _, err := router.Readers(context.Background(), nil, "dbname", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired table should be used when reading fails within the grace period
+	fail = errors.New("borrow fail")
+	n = n.Add(30 * time.Second)
+	readers, err := router.Readers(context.Background(), nil, "dbname", nil)
+	if err != nil || !reflect.DeepEqual(readers, table.Readers) {
+		t.Errorf("Expected stale readers but was %v, %v", readers, err)
+	}
+
+	// Should be kept by clean up during the grace period
+	router.CleanUp()
+	if len(router.dbRouters) != 1 {
+		t.Error("Should not have removed routing table within grace period")
+	}
+
+	// Outside of the grace period reading should fail
+	n = n.Add(time.Minute)
+	if _, err = router.Readers(context.Background(), nil, "dbname", nil); err == nil {
+		t.Error("Expected error outside of grace period")
+	}
+
+	// Invalidated tables should never be used
+	n = time.Unix(1000, 0)
+	fail = nil
+	router.Invalidate("dbname")
+	router.Readers(context.Background(), nil, "dbname", nil)
+	fail = errors.New("borrow fail")
+	router.Invalidate("dbname")
+	if _, err = router.Readers(context.Background(), nil, "dbname", nil); err == nil {
+		t.Error("Expected error when table is invalidated")
+	}
+}
+
+func TestRefreshExpiring(t *testing.T) {
+	numfetch := 0
+	table := &db.RoutingTable{TimeToLive: 10, Readers: []string{"rd1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			numfetch++
+			return &testutil.ConnFake{Table: table}, nil
+		},
+	}
+	n := time.Unix(1000, 0)
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+	router.now = func() time.Time { return n }
+
+	router.Readers(context.Background(), nil, "used", nil)
+	router.Readers(context.Background(), nil, "unused", nil)
+	assertNum(t, numfetch, 2, "Should have fetched initial")
+
+	// Tables far from expiring should not be refreshed
+	router.Readers(context.Background(), nil, "used", nil)
+	router.RefreshExpiring(context.Background(), 2*time.Second)
+	assertNum(t, numfetch, 2, "Should not have refreshed")
+
+	// Only the table that has been used since it was read should be refreshed
+	n = n.Add(9 * time.Second)
+	router.RefreshExpiring(context.Background(), 2*time.Second)
+	assertNum(t, numfetch, 3, "Should have refreshed used table")
+	router.RefreshExpiring(context.Background(), 2*time.Second)
+	assertNum(t, numfetch, 3, "Should not refresh table that has not been used since refresh")
+	_, expires, _ := router.Table(context.Background(), nil, "used", nil)
+	if !expires.Equal(n.Add(10 * time.Second)) {
+		t.Errorf("Unexpected expiry time %s", expires)
+	}
+}
+
+// Resolves the default database to the home database
+type homeDbConnFake struct {
+	testutil.ConnFake
+}
+
+func (c *homeDbConnFake) GetRoutingTable(context map[string]string, bookmarks []string, database, impersonatedUser string) (*db.RoutingTable, error) {
+	if database == db.DefaultDatabase {
+		database = "home"
+	}
+	return c.ConnFake.GetRoutingTable(context, bookmarks, database, impersonatedUser)
+}
+
+func TestHomeDatabaseCache(t *testing.T) {
+	numfetch := 0
+	table := &db.RoutingTable{TimeToLive: 100, Readers: []string{"rd1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			numfetch++
+			return &homeDbConnFake{testutil.ConnFake{Table: table}}, nil
+		},
+	}
+	n := time.Unix(1000, 0)
+	user := "user1"
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 200*time.Second, func() string { return user })
+	router.now = func() time.Time { return n }
+
+	assertHome := func(t *testing.T, impersonatedUser string, expectedFetches int) {
+		t.Helper()
+		database, err := router.GetNameOfDefaultDatabase(context.Background(), nil, impersonatedUser, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if database != "home" {
+			t.Errorf("Expected home database but was '%s'", database)
+		}
+		assertNum(t, numfetch, expectedFetches, "Unexpected number of fetches")
+	}
+
+	assertHome(t, "imp", 1)
+	assertHome(t, "imp", 1)
+	// Other impersonated user or other user should not use the cached entry
+	assertHome(t, "other", 2)
+	user = "user2"
+	assertHome(t, "imp", 3)
+
+	// Expired entries should be resolved again
+	n = n.Add(200 * time.Second)
+	assertHome(t, "imp", 4)
+
+	// Invalidated database should be resolved again
+	router.Invalidate("home")
+	assertHome(t, "imp", 5)
+	assertHome(t, "imp", 5)
+
+	// Changed routing table should resolve again
+	n = n.Add(100 * time.Second)
+	table = &db.RoutingTable{TimeToLive: 100, Readers: []string{"rd2"}}
+	router.Readers(context.Background(), nil, "home", nil)
+	assertHome(t, "imp", 7)
+	assertNum(t, len(router.homeDbs.entries), 1, "Should only have one entry left")
+
+	// Unknown users, like when authenticating with bearer tokens, should not share entries
+	user = ""
+	assertHome(t, "imp", 8)
+	assertHome(t, "imp", 9)
+
+	t.Run("Disabled", func(t *testing.T) {
+		router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+		numfetch = 0
+		router.GetNameOfDefaultDatabase(context.Background(), nil, "imp", nil)
+		router.GetNameOfDefaultDatabase(context.Background(), nil, "imp", nil)
+		assertNum(t, numfetch, 2, "Should not cache")
+	})
+}
+
+func TestSkipsServersWithOpenCircuit(t *testing.T) {
+	table := &db.RoutingTable{TimeToLive: 10, Readers: []string{"rd1", "rd2"}, Writers: []string{"wr1"}}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc, _ log.BoltLogger) (db.Connection, error) {
+			return &testutil.ConnFake{Table: table}, nil
+		},
+		open: map[string]bool{"rd1": true, "wr1": true},
+	}
+	router := New("router", func() []string { return []string{} }, nil, pool, logger, "routerid", event.Void{}, 0, 0, nil)
+
+	readers, err := router.Readers(context.Background(), nil, "dbname", nil)
+	if err != nil || !reflect.DeepEqual(readers, []string{"rd2"}) {
+		t.Errorf("Expected only reader without open circuit but was %v, %v", readers, err)
+	}
+	// When all servers are open they should all be returned
+	writers, err := router.Writers(context.Background(), nil, "dbname", nil)
+	if err != nil || !reflect.DeepEqual(writers, []string{"wr1"}) {
+		t.Errorf("Expected all writers but was %v, %v", writers, err)
+	}
+}
@@ -45,6 +45,7 @@ type ConnFake struct {
 	Version        string
 	Alive          bool
 	Birth          time.Time
+	Auth           map[string]interface{}
 	Table          *db.RoutingTable
 	Err            error
 	Id             int
@@ -54,6 +55,7 @@ type ConnFake struct {
 	RunStream      db.StreamHandle
 	RunTxErr       error
 	RunTxStream    db.StreamHandle
+	RunTxHook      func()
 	Nexts          []Next
 	Bookm          string
 	TxCommitErr    error
@@ -88,6 +90,10 @@ func (c *ConnFake) Birthdate() time.Time {
 	return c.Birth
 }
 
+func (c *ConnFake) AuthToken() map[string]interface{} {
+	return c.Auth
+}
+
 func (c *ConnFake) Bookmark() string {
 	return c.Bookm
 }
@@ -140,6 +146,9 @@ func (c *ConnFake) Run(runCommand db.Command, txConfig db.TxConfig) (db.StreamHa
 }
 
 func (c *ConnFake) RunTx(tx db.TxHandle, runCommand db.Command) (db.StreamHandle, error) {
+	if c.RunTxHook != nil {
+		c.RunTxHook()
+	}
 	return c.RunTxStream, c.RunTxErr
 }
 
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package testutil
+
+import (
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+)
+
+type ListenerFake struct {
+	mut    sync.Mutex
+	events []event.Event
+}
+
+func (l *ListenerFake) OnEvent(e event.Event) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *ListenerFake) Events() []event.Event {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	events := make([]event.Event, len(l.events))
+	copy(events, l.events)
+	return events
+}
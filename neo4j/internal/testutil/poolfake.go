@@ -30,6 +30,7 @@ type PoolFake struct {
 	BorrowConn  db.Connection
 	BorrowErr   error
 	ReturnHook  func()
+	FailedErrs  []error
 	CleanUpHook func()
 }
 
@@ -43,6 +44,10 @@ func (p *PoolFake) Return(c db.Connection) {
 	}
 }
 
+func (p *PoolFake) Failed(c db.Connection, err error) {
+	p.FailedErrs = append(p.FailedErrs, err)
+}
+
 func (p *PoolFake) CleanUp() {
 	if p.CleanUpHook != nil {
 		p.CleanUpHook()
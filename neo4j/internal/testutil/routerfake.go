@@ -34,6 +34,8 @@ type RouterFake struct {
 	Err                    error
 	CleanUpHook            func()
 	GetNameOfDefaultDbHook func(user string) (string, error)
+	KnownServersRet        []string
+	KnownReadWriteRet      []string
 }
 
 func (r *RouterFake) Invalidate(database string) {
@@ -67,3 +69,11 @@ func (r *RouterFake) CleanUp() {
 		r.CleanUpHook()
 	}
 }
+
+func (r *RouterFake) KnownServers() []string {
+	return r.KnownServersRet
+}
+
+func (r *RouterFake) KnownReadersAndWriters() []string {
+	return r.KnownReadWriteRet
+}
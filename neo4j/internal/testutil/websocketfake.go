@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testutil
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// WebSocketFake is the server end of a WebSocket connection, it gives access to the frames
+// sent by the client.
+type WebSocketFake struct {
+	Conn   net.Conn
+	Reader *bufio.Reader
+	Path   string
+	Host   string
+}
+
+// AcceptWebSocket performs the server side of the WebSocket opening handshake.
+func AcceptWebSocket(conn net.Conn) (*WebSocketFake, error) {
+	reader := bufio.NewReader(conn)
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, err
+	}
+	if request.Header.Get("Upgrade") != "websocket" || request.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("not a WebSocket request")
+	}
+	hash := sha1.Sum([]byte(request.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	_, err = fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(hash[:]))
+	if err != nil {
+		return nil, err
+	}
+	return &WebSocketFake{Conn: conn, Reader: reader, Path: request.URL.Path, Host: request.Host}, nil
+}
+
+// ReadFrame reads a frame sent by the client and returns the opcode and unmasked payload.
+func (w *WebSocketFake) ReadFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(w.Reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	if header[1]&0x80 == 0 {
+		return 0, nil, errors.New("client frame is not masked")
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(w.Reader, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(w.Reader, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(w.Reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(w.Reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return header[0] & 0x0f, payload, nil
+}
+
+// ReadBinary reads binary frames until n bytes of payload have been received.
+func (w *WebSocketFake) ReadBinary(n int) ([]byte, error) {
+	var payload []byte
+	for len(payload) < n {
+		opcode, p, err := w.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		if opcode != 0x2 {
+			return nil, fmt.Errorf("expected binary frame but was %d", opcode)
+		}
+		payload = append(payload, p...)
+	}
+	return payload, nil
+}
+
+// WriteFrame writes an unmasked frame, as servers do.
+func (w *WebSocketFake) WriteFrame(fin bool, opcode byte, payload []byte) error {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 126, byte(len(payload)>>8), byte(len(payload)))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(len(payload)))
+		frame = append(append(frame, 127), ext[:]...)
+	}
+	_, err := w.Conn.Write(append(frame, payload...))
+	return err
+}
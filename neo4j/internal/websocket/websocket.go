@@ -0,0 +1,271 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package websocket implements the client side of the WebSocket protocol, RFC 6455, to the
+// extent needed to carry Bolt in binary messages.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+type HandshakeError struct {
+	msg string
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("WebSocket handshake failed: %s", e.msg)
+}
+
+type ProtocolError struct {
+	msg string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("WebSocket protocol error: %s", e.msg)
+}
+
+// Conn carries the bytes written to it in binary WebSocket messages and returns the payload
+// of received binary messages when read.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+	// Unread part of the current frame
+	remaining int64
+	masked    bool
+	mask      [4]byte
+	maskPos   int
+	writeMut  sync.Mutex
+	closeOnce sync.Once
+}
+
+// Client performs the opening handshake on an established connection and returns a
+// connection that reads and writes the payload of WebSocket messages.
+func Client(conn net.Conn, host, path string) (*Conn, error) {
+	if path == "" {
+		path = "/"
+	}
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	request, err := http.NewRequest("GET", "http://"+host+path, nil)
+	if err != nil {
+		return nil, &HandshakeError{msg: err.Error()}
+	}
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	if err = request.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		return nil, &HandshakeError{msg: fmt.Sprintf("server responded with %s", response.Status)}
+	}
+	if !strings.EqualFold(response.Header.Get("Upgrade"), "websocket") {
+		return nil, &HandshakeError{msg: "server did not upgrade to WebSocket"}
+	}
+	if response.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, &HandshakeError{msg: "server responded with invalid accept key"}
+	}
+	return &Conn{Conn: conn, reader: reader}, nil
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if err := c.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.reader.Read(p)
+	c.unmask(p[:n])
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// Reads frame headers until the start of a frame with binary payload. Control frames are
+// handled on the way.
+func (c *Conn) nextFrame() error {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return err
+	}
+	opcode := header[0] & 0x0f
+	c.masked = header[1]&0x80 != 0
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			return &ProtocolError{msg: "invalid frame length"}
+		}
+	}
+	if c.masked {
+		if _, err := io.ReadFull(c.reader, c.mask[:]); err != nil {
+			return err
+		}
+	}
+	c.maskPos = 0
+
+	switch opcode {
+	case opBinary, opContinuation:
+		c.remaining = length
+		return nil
+	case opClose, opPing, opPong:
+		if length > 125 {
+			return &ProtocolError{msg: "control frame too long"}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return err
+		}
+		c.unmask(payload)
+		switch opcode {
+		case opClose:
+			// Echo the status code as acknowledgement
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			c.sendClose(payload)
+			return io.EOF
+		case opPing:
+			return c.writeFrame(opPong, payload)
+		}
+		return nil
+	case opText:
+		return &ProtocolError{msg: "unexpected text message"}
+	default:
+		return &ProtocolError{msg: fmt.Sprintf("unknown opcode %d", opcode)}
+	}
+}
+
+func (c *Conn) unmask(p []byte) {
+	if !c.masked {
+		return
+	}
+	for i := range p {
+		p[i] ^= c.mask[c.maskPos%4]
+		c.maskPos++
+	}
+}
+
+// Writes p as a single binary message.
+func (c *Conn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(opBinary, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Writes a complete frame, clients must mask the payload of all frames.
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+	_, err := c.Conn.Write(frame)
+	return err
+}
+
+// Sends a close frame once, errors are ignored since the connection is about to be closed.
+func (c *Conn) sendClose(payload []byte) {
+	c.closeOnce.Do(func() {
+		c.Conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.writeFrame(opClose, payload)
+	})
+}
+
+// Close sends a close frame and closes the underlying connection without waiting for the
+// server to acknowledge.
+func (c *Conn) Close() error {
+	c.sendClose([]byte{0x03, 0xe8}) // Normal closure
+	return c.Conn.Close()
+}
+
+var _ net.Conn = (*Conn)(nil)
@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+)
+
+// Connects a client to a stand-in server that runs serve on its end of the connection.
+func connect(t *testing.T, path string, serve func(server *testutil.WebSocketFake)) *Conn {
+	t.Helper()
+	clientEnd, serverEnd := net.Pipe()
+	t.Cleanup(func() { clientEnd.Close(); serverEnd.Close() })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server, err := testutil.AcceptWebSocket(serverEnd)
+		if err != nil {
+			t.Errorf("Server handshake failed: %s", err)
+			return
+		}
+		if server.Path != path || server.Host != "neo4j.internal" {
+			t.Errorf("Unexpected host %s and path %s", server.Host, server.Path)
+		}
+		serve(server)
+	}()
+	t.Cleanup(func() { <-done })
+	conn, err := Client(clientEnd, "neo4j.internal", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func assertRead(t *testing.T, conn *Conn, expected []byte) {
+	t.Helper()
+	buf := make([]byte, len(expected))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, expected) {
+		t.Errorf("Expected to read %v but was %v", expected, buf)
+	}
+}
+
+func TestWebSocket(ot *testing.T) {
+	ot.Run("Writes masked binary messages", func(t *testing.T) {
+		sizes := []int{0, 125, 126, 0xffff, 0x10000}
+		conn := connect(t, "/bolt", func(server *testutil.WebSocketFake) {
+			for _, size := range sizes {
+				opcode, payload, err := server.ReadFrame()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if opcode != opBinary || len(payload) != size {
+					t.Errorf("Expected binary frame of %d bytes but was %d of %d bytes", size, opcode, len(payload))
+				}
+				for _, b := range payload {
+					if b != byte(size) {
+						t.Errorf("Payload was not unmasked")
+						break
+					}
+				}
+			}
+		})
+		for _, size := range sizes {
+			n, err := conn.Write(bytes.Repeat([]byte{byte(size)}, size))
+			if err != nil || n != size {
+				t.Fatalf("Failed to write %d bytes: %d %v", size, n, err)
+			}
+		}
+	})
+
+	ot.Run("Reads fragmented messages", func(t *testing.T) {
+		conn := connect(t, "/", func(server *testutil.WebSocketFake) {
+			server.WriteFrame(false, opBinary, []byte{1, 2})
+			server.WriteFrame(false, opContinuation, []byte{})
+			server.WriteFrame(true, opContinuation, []byte{3})
+			server.WriteFrame(true, opBinary, bytes.Repeat([]byte{4}, 300))
+		})
+		assertRead(t, conn, []byte{1, 2, 3})
+		assertRead(t, conn, bytes.Repeat([]byte{4}, 300))
+	})
+
+	ot.Run("Answers ping with pong", func(t *testing.T) {
+		conn := connect(t, "/", func(server *testutil.WebSocketFake) {
+			server.WriteFrame(true, opPing, []byte("hello"))
+			opcode, payload, err := server.ReadFrame()
+			if err != nil || opcode != opPong || string(payload) != "hello" {
+				t.Errorf("Expected pong but was %d %s %v", opcode, payload, err)
+			}
+			server.WriteFrame(true, opBinary, []byte{1})
+		})
+		assertRead(t, conn, []byte{1})
+	})
+
+	ot.Run("Acknowledges close", func(t *testing.T) {
+		conn := connect(t, "/", func(server *testutil.WebSocketFake) {
+			server.WriteFrame(true, opClose, []byte{0x03, 0xe8, 'b', 'y', 'e'})
+			opcode, payload, err := server.ReadFrame()
+			if err != nil || opcode != opClose || !bytes.Equal(payload, []byte{0x03, 0xe8}) {
+				t.Errorf("Expected close but was %d %v %v", opcode, payload, err)
+			}
+		})
+		_, err := conn.Read(make([]byte, 1))
+		if err != io.EOF {
+			t.Errorf("Expected EOF but was %v", err)
+		}
+	})
+
+	ot.Run("Sends close when closed", func(t *testing.T) {
+		conn := connect(t, "/", func(server *testutil.WebSocketFake) {
+			opcode, _, err := server.ReadFrame()
+			if err != nil || opcode != opClose {
+				t.Errorf("Expected close but was %d %v", opcode, err)
+			}
+		})
+		conn.Close()
+	})
+
+	ot.Run("Rejects text messages", func(t *testing.T) {
+		conn := connect(t, "/", func(server *testutil.WebSocketFake) {
+			server.WriteFrame(true, opText, []byte("text"))
+		})
+		_, err := conn.Read(make([]byte, 1))
+		if _, isProtocolErr := err.(*ProtocolError); !isProtocolErr {
+			t.Errorf("Expected protocol error but was %v", err)
+		}
+	})
+}
+
+func TestWebSocketHandshake(ot *testing.T) {
+	handshake := func(t *testing.T, response string) error {
+		clientEnd, serverEnd := net.Pipe()
+		defer clientEnd.Close()
+		go func() {
+			defer serverEnd.Close()
+			http := make([]byte, 1024)
+			serverEnd.Read(http)
+			serverEnd.Write([]byte(response))
+		}()
+		_, err := Client(clientEnd, "neo4j.internal", "/")
+		return err
+	}
+
+	ot.Run("Not upgraded", func(t *testing.T) {
+		err := handshake(t, "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n")
+		if _, isHandshakeErr := err.(*HandshakeError); !isHandshakeErr {
+			t.Errorf("Expected handshake error but was %v", err)
+		}
+	})
+
+	ot.Run("Invalid accept key", func(t *testing.T) {
+		err := handshake(t, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: nope\r\n\r\n")
+		if _, isHandshakeErr := err.(*HandshakeError); !isHandshakeErr {
+			t.Errorf("Expected handshake error but was %v", err)
+		}
+	})
+}
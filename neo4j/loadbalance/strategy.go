@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package loadbalance defines how the driver chooses among multiple servers that
+// can serve a request, for example among the readers in a cluster.
+package loadbalance
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ServerStats contains statistics about a candidate server as tracked by the
+// connection pool.
+type ServerStats struct {
+	// Server is the address of the server.
+	Server string
+	// InUse is the number of connections to the server that are currently borrowed.
+	InUse int
+	// Idle is the number of connections to the server that are ready to be used.
+	Idle int
+	// RecentFailures is the number of consecutive failed connects to the server,
+	// failures are forgotten after a successful connect or after a while.
+	RecentFailures int
+	// Latency is an exponentially weighted moving average of the time it takes
+	// the server to respond to a query, from sending the query until the first
+	// response. Zero when there is no measurement yet.
+	Latency time.Duration
+}
+
+// Strategy orders candidate servers by preference. The driver will try to get a
+// connection from the servers in the returned order, servers that are left out
+// will not be tried. Servers that aren't among the candidates are ignored, if
+// none of the returned servers is a candidate the driver falls back to its
+// default order.
+//
+// Order is called concurrently from multiple goroutines and should not modify
+// the candidates.
+type Strategy interface {
+	Order(candidates []ServerStats) []string
+}
+
+// LeastConnected returns a strategy that prefers the server with the fewest
+// connections in use. Servers with recent failures are tried last.
+func LeastConnected() Strategy {
+	return &leastConnected{}
+}
+
+// RoundRobin returns a strategy that rotates among the servers on every call.
+// Servers with recent failures are tried last.
+func RoundRobin() Strategy {
+	return &roundRobin{}
+}
+
+// LatencyAware returns a strategy that prefers the server with the lowest
+// latency. Servers without a latency measurement are preferred to get a
+// measurement for them. Servers with recent failures are tried last.
+func LatencyAware() Strategy {
+	return &latencyAware{}
+}
+
+type leastConnected struct{}
+
+func (s *leastConnected) Order(candidates []ServerStats) []string {
+	return orderBy(candidates, func(x, y *ServerStats) bool {
+		if x.InUse != y.InUse {
+			return x.InUse < y.InUse
+		}
+		return x.Idle > y.Idle
+	})
+}
+
+type roundRobin struct {
+	next uint32
+}
+
+func (s *roundRobin) Order(candidates []ServerStats) []string {
+	n := len(candidates)
+	if n == 0 {
+		return nil
+	}
+	start := int((atomic.AddUint32(&s.next, 1) - 1) % uint32(n))
+	rotated := make([]ServerStats, n)
+	copy(rotated, candidates[start:])
+	copy(rotated[n-start:], candidates[:start])
+	return orderBy(rotated, func(x, y *ServerStats) bool { return false })
+}
+
+type latencyAware struct{}
+
+func (s *latencyAware) Order(candidates []ServerStats) []string {
+	return orderBy(candidates, func(x, y *ServerStats) bool {
+		if x.Latency != y.Latency {
+			return x.Latency < y.Latency
+		}
+		return x.InUse < y.InUse
+	})
+}
+
+// Orders the candidates by less but always puts servers with recent failures last.
+// The order among equal candidates is preserved.
+func orderBy(candidates []ServerStats, less func(x, y *ServerStats) bool) []string {
+	sorted := make([]ServerStats, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		x, y := &sorted[i], &sorted[j]
+		if (x.RecentFailures > 0) != (y.RecentFailures > 0) {
+			return y.RecentFailures > 0
+		}
+		return less(x, y)
+	})
+	servers := make([]string, len(sorted))
+	for i, s := range sorted {
+		servers[i] = s.Server
+	}
+	return servers
+}
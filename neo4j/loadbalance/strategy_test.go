@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package loadbalance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func assertOrder(t *testing.T, actual []string, expected ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected order %v but was %v", expected, actual)
+	}
+}
+
+func TestLeastConnected(t *testing.T) {
+	strategy := LeastConnected()
+	candidates := []ServerStats{
+		{Server: "a", InUse: 3},
+		{Server: "b", InUse: 0, RecentFailures: 1},
+		{Server: "c", InUse: 1},
+		{Server: "d", InUse: 1, Idle: 2},
+	}
+
+	assertOrder(t, strategy.Order(candidates), "d", "c", "a", "b")
+}
+
+func TestRoundRobin(t *testing.T) {
+	strategy := RoundRobin()
+	candidates := []ServerStats{{Server: "a"}, {Server: "b"}, {Server: "c"}}
+
+	assertOrder(t, strategy.Order(candidates), "a", "b", "c")
+	assertOrder(t, strategy.Order(candidates), "b", "c", "a")
+	assertOrder(t, strategy.Order(candidates), "c", "a", "b")
+	assertOrder(t, strategy.Order(candidates), "a", "b", "c")
+
+	candidates[1].RecentFailures = 2
+	assertOrder(t, strategy.Order(candidates), "c", "a", "b")
+	assertOrder(t, strategy.Order(nil))
+}
+
+func TestLatencyAware(t *testing.T) {
+	strategy := LatencyAware()
+	candidates := []ServerStats{
+		{Server: "a", Latency: 30 * time.Millisecond},
+		{Server: "b", Latency: 10 * time.Millisecond, RecentFailures: 1},
+		{Server: "c", Latency: 20 * time.Millisecond, InUse: 2},
+		{Server: "d", Latency: 20 * time.Millisecond, InUse: 1},
+		{Server: "e"},
+	}
+
+	assertOrder(t, strategy.Order(candidates), "e", "d", "c", "a", "b")
+}
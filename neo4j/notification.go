@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+)
+
+// NotificationSeverity defines the severity level of a notification
+type NotificationSeverity string
+
+const (
+	// NotificationSeverityWarning identifies a notification about something that should be fixed
+	NotificationSeverityWarning NotificationSeverity = "WARNING"
+	// NotificationSeverityInformation identifies a notification that is informational
+	NotificationSeverityInformation NotificationSeverity = "INFORMATION"
+	// NotificationSeverityUnknown identifies a severity that the driver doesn't know about
+	NotificationSeverityUnknown NotificationSeverity = "UNKNOWN"
+)
+
+// NotificationCategory defines the category of a notification
+type NotificationCategory string
+
+const (
+	// NotificationCategoryHint identifies notifications about hints that could not be fulfilled
+	NotificationCategoryHint NotificationCategory = "HINT"
+	// NotificationCategoryUnrecognized identifies notifications about unknown labels, types or properties
+	NotificationCategoryUnrecognized NotificationCategory = "UNRECOGNIZED"
+	// NotificationCategoryUnsupported identifies notifications about unsupported or experimental features
+	NotificationCategoryUnsupported NotificationCategory = "UNSUPPORTED"
+	// NotificationCategoryPerformance identifies notifications about queries that might perform badly
+	NotificationCategoryPerformance NotificationCategory = "PERFORMANCE"
+	// NotificationCategoryDeprecation identifies notifications about deprecated features
+	NotificationCategoryDeprecation NotificationCategory = "DEPRECATION"
+	// NotificationCategoryGeneric identifies notifications that don't belong to any other category
+	NotificationCategoryGeneric NotificationCategory = "GENERIC"
+	// NotificationCategoryUnknown identifies a category that the driver doesn't know about
+	NotificationCategoryUnknown NotificationCategory = "UNKNOWN"
+)
+
+// Codes of common notifications
+const (
+	NotificationCodeDeprecatedFeature              = "Neo.ClientNotification.Statement.FeatureDeprecationWarning"
+	NotificationCodeDeprecatedProcedure            = "Neo.ClientNotification.Statement.DeprecatedProcedureWarning"
+	NotificationCodeCartesianProduct               = "Neo.ClientNotification.Statement.CartesianProductWarning"
+	NotificationCodeUnboundedVariableLengthPattern = "Neo.ClientNotification.Statement.UnboundedVariableLengthPatternWarning"
+	NotificationCodeExhaustiveShortestPath         = "Neo.ClientNotification.Statement.ExhaustiveShortestPathWarning"
+	NotificationCodeEagerOperator                  = "Neo.ClientNotification.Statement.EagerOperatorWarning"
+	NotificationCodeNoApplicableIndex              = "Neo.ClientNotification.Statement.NoApplicableIndexWarning"
+	NotificationCodeUnknownLabel                   = "Neo.ClientNotification.Statement.UnknownLabelWarning"
+	NotificationCodeUnknownRelationshipType        = "Neo.ClientNotification.Statement.UnknownRelationshipTypeWarning"
+	NotificationCodeUnknownPropertyKey             = "Neo.ClientNotification.Statement.UnknownPropertyKeyWarning"
+	NotificationCodeJoinHintUnfulfillable          = "Neo.ClientNotification.Statement.JoinHintUnfulfillableWarning"
+	NotificationCodeHintedIndexNotFound            = "Neo.ClientNotification.Schema.HintedIndexNotFound"
+	NotificationCodeExperimentalFeature            = "Neo.ClientNotification.Statement.ExperimentalFeature"
+)
+
+// Categories of well known codes, used for servers that don't send categories
+var notificationCodeCategories = map[string]NotificationCategory{
+	NotificationCodeDeprecatedFeature:              NotificationCategoryDeprecation,
+	NotificationCodeDeprecatedProcedure:            NotificationCategoryDeprecation,
+	NotificationCodeCartesianProduct:               NotificationCategoryPerformance,
+	NotificationCodeUnboundedVariableLengthPattern: NotificationCategoryPerformance,
+	NotificationCodeExhaustiveShortestPath:         NotificationCategoryPerformance,
+	NotificationCodeEagerOperator:                  NotificationCategoryPerformance,
+	NotificationCodeNoApplicableIndex:              NotificationCategoryPerformance,
+	NotificationCodeUnknownLabel:                   NotificationCategoryUnrecognized,
+	NotificationCodeUnknownRelationshipType:        NotificationCategoryUnrecognized,
+	NotificationCodeUnknownPropertyKey:             NotificationCategoryUnrecognized,
+	NotificationCodeJoinHintUnfulfillable:          NotificationCategoryHint,
+	NotificationCodeHintedIndexNotFound:            NotificationCategoryHint,
+	NotificationCodeExperimentalFeature:            NotificationCategoryUnsupported,
+}
+
+func notificationSeverity(severity string) NotificationSeverity {
+	switch s := NotificationSeverity(strings.ToUpper(severity)); s {
+	case NotificationSeverityWarning, NotificationSeverityInformation:
+		return s
+	}
+	return NotificationSeverityUnknown
+}
+
+func notificationCategory(category, code string) NotificationCategory {
+	if category == "" {
+		if c, known := notificationCodeCategories[code]; known {
+			return c
+		}
+		return NotificationCategoryUnknown
+	}
+	switch c := NotificationCategory(strings.ToUpper(category)); c {
+	case NotificationCategoryHint, NotificationCategoryUnrecognized, NotificationCategoryUnsupported,
+		NotificationCategoryPerformance, NotificationCategoryDeprecation, NotificationCategoryGeneric:
+		return c
+	}
+	return NotificationCategoryUnknown
+}
+
+// Logs warning notifications of results when Config.LogNotifications is set.
+type notificationLogger struct {
+	log   log.Logger
+	logId string
+}
+
+func (l *notificationLogger) logWarnings(cypher string, summary *db.Summary) {
+	for _, n := range summary.Notifications {
+		if notificationSeverity(n.Severity) != NotificationSeverityWarning {
+			continue
+		}
+		if n.Position != nil {
+			l.log.Warnf(log.Session, l.logId, "%s: %s (%s) at line %d, column %d in query: %s",
+				n.Code, n.Title, n.Description, n.Position.Line, n.Position.Column, cypher)
+		} else {
+			l.log.Warnf(log.Session, l.logId, "%s: %s (%s) in query: %s",
+				n.Code, n.Title, n.Description, cypher)
+		}
+	}
+}
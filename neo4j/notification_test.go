@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+)
+
+type warningLogger struct {
+	warnings []string
+}
+
+func (l *warningLogger) Error(name, id string, err error)                        {}
+func (l *warningLogger) Infof(name, id string, msg string, args ...interface{})  {}
+func (l *warningLogger) Debugf(name, id string, msg string, args ...interface{}) {}
+func (l *warningLogger) Warnf(name string, id string, msg string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(msg, args...))
+}
+
+func TestNotificationTypes(t *testing.T) {
+	cases := []struct {
+		notification db.Notification
+		severity     NotificationSeverity
+		category     NotificationCategory
+	}{
+		{db.Notification{Severity: "WARNING", Category: "DEPRECATION"}, NotificationSeverityWarning, NotificationCategoryDeprecation},
+		{db.Notification{Severity: "information", Category: "hint"}, NotificationSeverityInformation, NotificationCategoryHint},
+		{db.Notification{Severity: "WARNING", Code: NotificationCodeCartesianProduct}, NotificationSeverityWarning, NotificationCategoryPerformance},
+		{db.Notification{Severity: "?", Code: "Neo.ClientNotification.Whatever"}, NotificationSeverityUnknown, NotificationCategoryUnknown},
+		{db.Notification{Category: "NEW", Code: NotificationCodeCartesianProduct}, NotificationSeverityUnknown, NotificationCategoryUnknown},
+	}
+	for _, c := range cases {
+		n := &notification{notification: &c.notification}
+		if n.SeverityLevel() != c.severity || n.Category() != c.category {
+			t.Errorf("Expected %s and %s for %+v but was %s and %s",
+				c.severity, c.category, c.notification, n.SeverityLevel(), n.Category())
+		}
+	}
+}
+
+func TestLogNotifications(t *testing.T) {
+	summary := &db.Summary{Notifications: []db.Notification{
+		{Code: NotificationCodeDeprecatedFeature, Title: "Deprecated", Description: "Use X", Severity: "WARNING",
+			Position: &db.InputPosition{Line: 2, Column: 5}},
+		{Code: "Neo.ClientNotification.Info", Severity: "INFORMATION"},
+		{Code: NotificationCodeCartesianProduct, Title: "Product", Description: "Slow", Severity: "WARNING"},
+	}}
+	logger := &warningLogger{}
+	conn := &ConnFake{Nexts: []Next{{Record: &db.Record{}}, {Summary: summary}}, ConsumeSum: summary}
+	res := newResult(conn, db.StreamHandle(0), "MATCH (a), (b) RETURN *", nil)
+	res.notifications = &notificationLogger{log: logger, logId: "1"}
+
+	AssertTrue(t, res.Next())
+	AssertIntEqual(t, len(logger.warnings), 0)
+	AssertFalse(t, res.Next())
+	_, err := res.Consume()
+	AssertNoError(t, err)
+	expected := []string{
+		NotificationCodeDeprecatedFeature + ": Deprecated (Use X) at line 2, column 5 in query: MATCH (a), (b) RETURN *",
+		NotificationCodeCartesianProduct + ": Product (Slow) in query: MATCH (a), (b) RETURN *",
+	}
+	AssertIntEqual(t, len(logger.warnings), len(expected))
+	for i := range expected {
+		AssertStringEqual(t, logger.warnings[i], expected[i])
+	}
+}
+
+func TestLogNotificationsOfResultsNotConsumed(outer *testing.T) {
+	summary := &db.Summary{Notifications: []db.Notification{
+		{Code: NotificationCodeCartesianProduct, Title: "Product", Description: "Slow", Severity: "WARNING"},
+	}}
+	newSessionLogging := func() (*session, *ConnFake, *warningLogger) {
+		logger := &warningLogger{}
+		conn := &ConnFake{Alive: true, ConsumeSum: summary}
+		pool := &PoolFake{BorrowConn: conn}
+		conf := Config{LogNotifications: true}
+		sess := newSession(&conf, SessionConfig{}, &RouterFake{}, pool, logger, &event.Void{}, nil)
+		return sess, conn, logger
+	}
+	work := func(tx Transaction) (interface{}, error) {
+		_, err := tx.Run("MATCH (a), (b) RETURN *", nil)
+		return nil, err
+	}
+
+	outer.Run("Buffered by next query", func(t *testing.T) {
+		sess, conn, logger := newSessionLogging()
+		buffered := false
+		conn.BufferHook = func() { buffered = true }
+		_, err := sess.Run("MATCH (a), (b) RETURN *", nil)
+		AssertNoError(t, err)
+		AssertIntEqual(t, len(logger.warnings), 0)
+
+		_, err = sess.Run("RETURN 1", nil)
+		AssertNoError(t, err)
+		AssertTrue(t, buffered)
+		AssertIntEqual(t, len(logger.warnings), 1)
+	})
+
+	outer.Run("Discarded by session close", func(t *testing.T) {
+		sess, _, logger := newSessionLogging()
+		_, err := sess.Run("MATCH (a), (b) RETURN *", nil)
+		AssertNoError(t, err)
+		sess.Close()
+		AssertIntEqual(t, len(logger.warnings), 1)
+	})
+
+	outer.Run("Discarded by commit", func(t *testing.T) {
+		sess, _, logger := newSessionLogging()
+		tx, err := sess.BeginTransaction()
+		AssertNoError(t, err)
+		_, err = work(tx)
+		AssertNoError(t, err)
+		AssertIntEqual(t, len(logger.warnings), 0)
+		AssertNoError(t, tx.Commit())
+		AssertIntEqual(t, len(logger.warnings), 1)
+	})
+
+	outer.Run("Discarded by rollback", func(t *testing.T) {
+		sess, _, logger := newSessionLogging()
+		tx, err := sess.BeginTransaction()
+		AssertNoError(t, err)
+		_, err = work(tx)
+		AssertNoError(t, err)
+		AssertNoError(t, tx.Close())
+		AssertIntEqual(t, len(logger.warnings), 1)
+	})
+
+	outer.Run("Discarded by transaction function", func(t *testing.T) {
+		sess, _, logger := newSessionLogging()
+		_, err := sess.WriteTransaction(work)
+		AssertNoError(t, err)
+		AssertIntEqual(t, len(logger.warnings), 1)
+	})
+}
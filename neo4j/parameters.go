@@ -0,0 +1,162 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+)
+
+// ValidateParameters checks that all values in the query parameters, including nested values
+// in lists and maps, are of types that can be sent to the database. Returns a UsageError that
+// includes the path to the first unsupported value, like params["rows"][17]["meta"].
+// Parameters are validated by Session.Run and Transaction.Run before they are sent, this
+// function is useful to detect problems earlier, in tests for example.
+func ValidateParameters(params map[string]interface{}) error {
+	if invalid := validateParameter(reflect.ValueOf(params)); invalid != nil {
+		return invalid.usageError()
+	}
+	return nil
+}
+
+// Unsupported value found by validateParameter. The path to the value is collected on the way
+// back up, innermost step first, since it is only needed when something is wrong.
+type invalidParameter struct {
+	typ reflect.Type
+	// Indexes into lists and keys into maps
+	path []interface{}
+}
+
+func (p *invalidParameter) within(step interface{}) *invalidParameter {
+	p.path = append(p.path, step)
+	return p
+}
+
+func (p *invalidParameter) usageError() error {
+	path := "params"
+	for i := len(p.path) - 1; i >= 0; i-- {
+		switch step := p.path[i].(type) {
+		case int:
+			path += fmt.Sprintf("[%d]", step)
+		case string:
+			path += fmt.Sprintf("[%q]", step)
+		}
+	}
+	return &UsageError{Message: fmt.Sprintf(
+		"Usage of type '%s' is not supported in %s", p.typ.String(), path)}
+}
+
+// Structs, and pointers to structs, that the Bolt protocol implementation knows how to pack.
+var supportedStructs = map[reflect.Type]bool{
+	reflect.TypeOf(dbtype.Point2D{}):       true,
+	reflect.TypeOf(&dbtype.Point2D{}):      true,
+	reflect.TypeOf(dbtype.Point3D{}):       true,
+	reflect.TypeOf(&dbtype.Point3D{}):      true,
+	reflect.TypeOf(time.Time{}):            true,
+	reflect.TypeOf(dbtype.Date{}):          true,
+	reflect.TypeOf(dbtype.Time{}):          true,
+	reflect.TypeOf(dbtype.LocalTime{}):     true,
+	reflect.TypeOf(dbtype.LocalDateTime{}): true,
+	reflect.TypeOf(dbtype.Duration{}):      true,
+}
+
+func isPrimitive(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// Mirrors the types that the Bolt protocol implementation knows how to pack.
+func validateParameter(v reflect.Value) *invalidParameter {
+	kind := v.Kind()
+	if isPrimitive(kind) {
+		return nil
+	}
+	switch kind {
+	case reflect.Invalid:
+		// Untyped nil
+		return nil
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if kind == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			return validateStruct(v)
+		}
+		return validateParameter(v.Elem())
+	case reflect.Struct:
+		return validateStruct(v)
+	case reflect.Slice:
+		// Lists of primitives, including []byte, can't contain anything unsupported
+		if isPrimitive(v.Type().Elem().Kind()) {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if invalid := validateParameter(v.Index(i)); invalid != nil {
+				return invalid.within(i)
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return &invalidParameter{typ: v.Type()}
+		}
+		if isPrimitive(v.Type().Elem().Kind()) {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if validateParameter(iter.Value()) != nil {
+				return firstInvalidEntry(v)
+			}
+		}
+		return nil
+	}
+	return &invalidParameter{typ: v.Type()}
+}
+
+// Map iteration order is random, the map is validated again in key order to always report the
+// same unsupported value.
+func firstInvalidEntry(v reflect.Value) *invalidParameter {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		if invalid := validateParameter(v.MapIndex(k)); invalid != nil {
+			return invalid.within(k.String())
+		}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value) *invalidParameter {
+	if supportedStructs[v.Type()] {
+		return nil
+	}
+	return &invalidParameter{typ: v.Type()}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+)
+
+func TestValidateParameters(outer *testing.T) {
+	outer.Run("Supported", func(t *testing.T) {
+		s := "s"
+		err := ValidateParameters(map[string]interface{}{
+			"nil":      nil,
+			"scalars":  []interface{}{true, 1, int8(1), uint64(1), 1.5, "s", &s, []byte{1}},
+			"temporal": []interface{}{time.Now(), dbtype.Date(time.Now()), dbtype.Duration{}},
+			"spatial":  []interface{}{dbtype.Point2D{}, &dbtype.Point3D{}},
+			"nested":   map[string][]map[string]interface{}{"a": {{"b": []int{1}}}},
+		})
+		AssertNoError(t, err)
+	})
+
+	cases := []struct {
+		name   string
+		params map[string]interface{}
+		path   string
+	}{
+		{"Channel", map[string]interface{}{"c": make(chan int)}, `params["c"]`},
+		{"Function in list", map[string]interface{}{"l": []interface{}{1, func() {}}}, `params["l"][1]`},
+		{"Map with int keys", map[string]interface{}{"m": map[int]string{}}, `params["m"]`},
+		{"Unknown struct pointer", map[string]interface{}{"t": &time.Time{}}, `params["t"]`},
+		{"First in key order", map[string]interface{}{"b": make(chan int), "a": 1, "c": func() {}}, `params["b"]`},
+		{"Nested", map[string]interface{}{
+			"rows": []map[string]interface{}{{}, {"meta": map[string]interface{}{"ok": 1, "bad": struct{}{}}}},
+		}, `params["rows"][1]["meta"]["bad"]`},
+	}
+	for _, c := range cases {
+		outer.Run(c.name, func(t *testing.T) {
+			err := ValidateParameters(c.params)
+			assertUsageError(t, err)
+			if !strings.HasSuffix(err.Error(), c.path) {
+				t.Errorf("Expected error to end with path %s but was: %s", c.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanFindingSeverity defines how risky a finding in a query plan is
+type PlanFindingSeverity int
+
+const (
+	// PlanFindingInfo identifies a finding that is worth knowing about but often expected
+	PlanFindingInfo PlanFindingSeverity = 1
+	// PlanFindingWarning identifies a finding that is likely to hurt performance
+	PlanFindingWarning PlanFindingSeverity = 2
+	// PlanFindingCritical identifies a finding that will hurt performance as data grows
+	PlanFindingCritical PlanFindingSeverity = 3
+)
+
+func (s PlanFindingSeverity) String() string {
+	switch s {
+	case PlanFindingInfo:
+		return "INFO"
+	case PlanFindingWarning:
+		return "WARNING"
+	case PlanFindingCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Kinds of findings reported by AnalyzePlan and AnalyzeProfile
+const (
+	// The plan combines all rows of two inputs, usually caused by disconnected patterns
+	PlanFindingCartesianProduct = "CartesianProduct"
+	// The plan materializes all rows before writing, which keeps them all in memory
+	PlanFindingEagerWrite = "EagerWrite"
+	// The plan scans all nodes in the database
+	PlanFindingAllNodesScan = "AllNodesScan"
+	// The plan scans all nodes with a label
+	PlanFindingLabelScan = "LabelScan"
+	// The operator produced far more rows than the planner estimated, statistics might be stale
+	PlanFindingEstimateExceeded = "EstimateExceeded"
+)
+
+// PlanFinding is a known anti-pattern found in a query plan.
+type PlanFinding struct {
+	// Kind is one of the PlanFinding kind constants
+	Kind     string
+	Severity PlanFindingSeverity
+	// Operator the finding is about
+	Operator string
+	// Path contains the operators from the root of the plan to the operator the finding is about
+	Path    []string
+	Message string
+}
+
+func (f PlanFinding) String() string {
+	return fmt.Sprintf("%s %s at %s: %s", f.Severity, f.Kind, strings.Join(f.Path, " > "), f.Message)
+}
+
+const (
+	// Actual rows must exceed the estimate by this factor to be reported
+	estimateExceededFactor = 10
+	// and be at least this many to avoid reporting small absolute differences.
+	estimateExceededMinRows = 1000
+)
+
+// AnalyzePlan walks the plan and reports known anti-patterns:
+//   - CartesianProduct operators.
+//   - Eager operators in plans that write.
+//   - AllNodesScan and NodeByLabelScan, reported as critical when the scanned nodes are filtered
+//     since an index seek was most likely expected.
+func AnalyzePlan(plan Plan) []PlanFinding {
+	return analyzePlan(planTree(plan))
+}
+
+// AnalyzeProfile reports the same anti-patterns as AnalyzePlan and operators that produced
+// far more rows than estimated.
+func AnalyzeProfile(profile ProfiledPlan) []PlanFinding {
+	return analyzePlan(profileTree(profile))
+}
+
+func analyzePlan(root *planNode) []PlanFinding {
+	if root == nil {
+		return nil
+	}
+	writes := planWrites(root)
+	findings := []PlanFinding{}
+	var visit func(n *planNode, path []string, filtered bool)
+	visit = func(n *planNode, path []string, filtered bool) {
+		operator := operatorName(n.Operator)
+		path = append(path[:len(path):len(path)], operator)
+		report := func(kind string, severity PlanFindingSeverity, message string) {
+			findings = append(findings, PlanFinding{
+				Kind: kind, Severity: severity, Operator: operator, Path: path, Message: message})
+		}
+
+		switch {
+		case operator == "CartesianProduct":
+			report(PlanFindingCartesianProduct, PlanFindingWarning,
+				"Combines every row of one input with every row of the other, check for disconnected patterns")
+		case operator == "Eager" && writes:
+			report(PlanFindingEagerWrite, PlanFindingWarning,
+				"Materializes all rows before writing, consider splitting the query or batching the writes")
+		case operator == "AllNodesScan":
+			severity := PlanFindingWarning
+			if filtered {
+				severity = PlanFindingCritical
+			}
+			report(PlanFindingAllNodesScan, severity, "Scans all nodes, add a label and an index to the pattern")
+		case operator == "NodeByLabelScan":
+			severity := PlanFindingInfo
+			if filtered {
+				severity = PlanFindingCritical
+			}
+			report(PlanFindingLabelScan, severity,
+				fmt.Sprintf("Scans all nodes with label (%s), an index on the filtered properties allows a seek", n.details()))
+		}
+
+		if n.planStats != nil && n.EstimatedRows != nil {
+			estimated := *n.EstimatedRows
+			if n.Rows >= estimateExceededMinRows && float64(n.Rows) > estimated*estimateExceededFactor {
+				report(PlanFindingEstimateExceeded, PlanFindingWarning,
+					fmt.Sprintf("Produced %d rows but %.0f were estimated, statistics might be outdated", n.Rows, estimated))
+			}
+		}
+
+		// Scans below a filter are most likely filtered on properties, as long as no other
+		// operator that reads the graph comes in between.
+		filtered = operator == "Filter" || (filtered && !readsGraph(operator))
+		for _, c := range n.Children {
+			visit(c, path, filtered)
+		}
+	}
+	visit(root, nil, false)
+	return findings
+}
+
+// Strips the planner or runtime suffix that some servers add, like Filter@neo4j.
+func operatorName(operator string) string {
+	if i := strings.IndexByte(operator, '@'); i >= 0 {
+		return operator[:i]
+	}
+	return operator
+}
+
+func planWrites(n *planNode) bool {
+	operator := operatorName(n.Operator)
+	for _, prefix := range []string{"Create", "Merge", "Delete", "DetachDelete", "Set", "Remove", "Foreach"} {
+		if strings.HasPrefix(operator, prefix) {
+			return true
+		}
+	}
+	for _, c := range n.Children {
+		if planWrites(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func readsGraph(operator string) bool {
+	return strings.HasSuffix(operator, "Scan") || strings.HasSuffix(operator, "Seek") ||
+		strings.Contains(operator, "Expand") || strings.Contains(operator, "Apply") ||
+		operator == "CartesianProduct" || strings.HasSuffix(operator, "Join")
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+)
+
+type expectedFinding struct {
+	kind     string
+	severity PlanFindingSeverity
+	path     []string
+}
+
+func assertFindings(t *testing.T, findings []PlanFinding, expected ...expectedFinding) {
+	t.Helper()
+	actual := []expectedFinding{}
+	for _, f := range findings {
+		actual = append(actual, expectedFinding{kind: f.Kind, severity: f.Severity, path: f.Path})
+	}
+	if len(expected) == 0 {
+		expected = []expectedFinding{}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected findings %v but were %v", expected, actual)
+	}
+}
+
+func TestAnalyzePlan(outer *testing.T) {
+	outer.Run("Index backed", func(t *testing.T) {
+		p := &plan{plan: &db.Plan{Operator: "ProduceResults@neo4j", Children: []db.Plan{
+			{Operator: "NodeIndexSeek@neo4j"},
+		}}}
+		assertFindings(t, AnalyzePlan(p))
+	})
+
+	outer.Run("Filtered label scan", func(t *testing.T) {
+		p := &plan{plan: &db.Plan{Operator: "ProduceResults@neo4j", Children: []db.Plan{
+			{Operator: "Filter@neo4j", Children: []db.Plan{
+				{Operator: "NodeByLabelScan@neo4j", Arguments: map[string]interface{}{"Details": "n:Person"}},
+			}},
+		}}}
+		assertFindings(t, AnalyzePlan(p),
+			expectedFinding{PlanFindingLabelScan, PlanFindingCritical, []string{"ProduceResults", "Filter", "NodeByLabelScan"}})
+	})
+
+	outer.Run("Unfiltered scans", func(t *testing.T) {
+		p := &plan{plan: &db.Plan{Operator: "ProduceResults", Children: []db.Plan{
+			{Operator: "CartesianProduct", Children: []db.Plan{
+				{Operator: "NodeByLabelScan"},
+				{Operator: "AllNodesScan"},
+			}},
+		}}}
+		assertFindings(t, AnalyzePlan(p),
+			expectedFinding{PlanFindingCartesianProduct, PlanFindingWarning, []string{"ProduceResults", "CartesianProduct"}},
+			expectedFinding{PlanFindingLabelScan, PlanFindingInfo, []string{"ProduceResults", "CartesianProduct", "NodeByLabelScan"}},
+			expectedFinding{PlanFindingAllNodesScan, PlanFindingWarning, []string{"ProduceResults", "CartesianProduct", "AllNodesScan"}})
+	})
+
+	outer.Run("Scan below expand is not filtered", func(t *testing.T) {
+		p := &plan{plan: &db.Plan{Operator: "Filter", Children: []db.Plan{
+			{Operator: "Expand(All)", Children: []db.Plan{{Operator: "NodeByLabelScan"}}},
+		}}}
+		assertFindings(t, AnalyzePlan(p),
+			expectedFinding{PlanFindingLabelScan, PlanFindingInfo, []string{"Filter", "Expand(All)", "NodeByLabelScan"}})
+	})
+
+	outer.Run("Eager", func(t *testing.T) {
+		read := &plan{plan: &db.Plan{Operator: "ProduceResults", Children: []db.Plan{
+			{Operator: "Eager", Children: []db.Plan{{Operator: "NodeIndexSeek"}}},
+		}}}
+		assertFindings(t, AnalyzePlan(read))
+		write := &plan{plan: &db.Plan{Operator: "EmptyResult", Children: []db.Plan{
+			{Operator: "Create", Children: []db.Plan{
+				{Operator: "Eager", Children: []db.Plan{{Operator: "NodeIndexSeek"}}},
+			}},
+		}}}
+		assertFindings(t, AnalyzePlan(write),
+			expectedFinding{PlanFindingEagerWrite, PlanFindingWarning, []string{"EmptyResult", "Create", "Eager"}})
+	})
+
+	outer.Run("Nil plan", func(t *testing.T) {
+		if findings := AnalyzePlan(nil); findings != nil {
+			t.Errorf("Expected no findings but were %v", findings)
+		}
+	})
+}
+
+func TestAnalyzeProfile(t *testing.T) {
+	p := &profile{profile: &db.ProfiledPlan{
+		Operator: "ProduceResults", Records: 5000, Arguments: map[string]interface{}{"EstimatedRows": 5000.0},
+		Children: []db.ProfiledPlan{
+			{Operator: "NodeIndexSeek", Records: 5000, Arguments: map[string]interface{}{"EstimatedRows": 12.0}},
+			{Operator: "NodeIndexScan", Records: 100, Arguments: map[string]interface{}{"EstimatedRows": 1.0}},
+		},
+	}}
+	findings := AnalyzeProfile(p)
+	assertFindings(t, findings,
+		expectedFinding{PlanFindingEstimateExceeded, PlanFindingWarning, []string{"ProduceResults", "NodeIndexSeek"}})
+	if s := findings[0].String(); s != "WARNING EstimateExceeded at ProduceResults > NodeIndexSeek: "+findings[0].Message {
+		t.Errorf("Unexpected string: %s", s)
+	}
+}
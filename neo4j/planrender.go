@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Plans and profiled plans converted to a common tree for rendering.
+type planNode struct {
+	Operator      string                 `json:"operator"`
+	Arguments     map[string]interface{} `json:"arguments,omitempty"`
+	Identifiers   []string               `json:"identifiers,omitempty"`
+	EstimatedRows *float64               `json:"estimatedRows,omitempty"`
+	*planStats
+	Children []*planNode `json:"children,omitempty"`
+}
+
+type planStats struct {
+	Rows              int64   `json:"rows"`
+	DbHits            int64   `json:"dbHits"`
+	PageCacheHits     int64   `json:"pageCacheHits"`
+	PageCacheMisses   int64   `json:"pageCacheMisses"`
+	PageCacheHitRatio float64 `json:"pageCacheHitRatio"`
+	// In nanoseconds
+	Time int64 `json:"time"`
+}
+
+func planTree(p Plan) *planNode {
+	if p == nil {
+		return nil
+	}
+	node := newPlanNode(p.Operator(), p.Arguments(), p.Identifiers())
+	for _, c := range p.Children() {
+		node.Children = append(node.Children, planTree(c))
+	}
+	return node
+}
+
+func profileTree(p ProfiledPlan) *planNode {
+	if p == nil {
+		return nil
+	}
+	node := newPlanNode(p.Operator(), p.Arguments(), p.Identifiers())
+	node.planStats = &planStats{
+		Rows:              p.Records(),
+		DbHits:            p.DbHits(),
+		PageCacheHits:     p.PageCacheHits(),
+		PageCacheMisses:   p.PageCacheMisses(),
+		PageCacheHitRatio: p.PageCacheHitRatio(),
+		Time:              p.Time(),
+	}
+	for _, c := range p.Children() {
+		node.Children = append(node.Children, profileTree(c))
+	}
+	return node
+}
+
+func newPlanNode(operator string, arguments map[string]interface{}, identifiers []string) *planNode {
+	node := &planNode{Operator: operator, Arguments: arguments, Identifiers: identifiers}
+	switch rows := arguments["EstimatedRows"].(type) {
+	case float64:
+		node.EstimatedRows = &rows
+	case int64:
+		f := float64(rows)
+		node.EstimatedRows = &f
+	}
+	return node
+}
+
+func (n *planNode) details() string {
+	details, _ := n.Arguments["Details"].(string)
+	return details
+}
+
+// PlanTable renders the plan as a text table like the one shown by Neo4j Browser and Cypher
+// Shell, with the operator tree, details and estimated rows.
+func PlanTable(plan Plan) string {
+	return renderTable(planTree(plan))
+}
+
+// ProfileTable renders the profiled plan as a text table like the one shown by Neo4j Browser
+// and Cypher Shell, with the operator tree, details, estimated rows, rows, db hits, page cache
+// hit ratio and time.
+func ProfileTable(profile ProfiledPlan) string {
+	return renderTable(profileTree(profile))
+}
+
+// PlanJSON exports the plan tree as JSON.
+func PlanJSON(plan Plan) ([]byte, error) {
+	return json.Marshal(planTree(plan))
+}
+
+// ProfileJSON exports the profiled plan tree as JSON, the time of each operator is in
+// nanoseconds.
+func ProfileJSON(profile ProfiledPlan) ([]byte, error) {
+	return json.Marshal(profileTree(profile))
+}
+
+// PlanDOT exports the plan tree as a Graphviz DOT digraph where records flow from the
+// children to the parents.
+func PlanDOT(plan Plan) string {
+	return renderDOT(planTree(plan))
+}
+
+// ProfileDOT exports the profiled plan tree as a Graphviz DOT digraph where records flow from
+// the children to the parents.
+func ProfileDOT(profile ProfiledPlan) string {
+	return renderDOT(profileTree(profile))
+}
+
+func renderTable(root *planNode) string {
+	if root == nil {
+		return ""
+	}
+	profiled := root.planStats != nil
+	header := []string{"Operator", "Details", "Estimated Rows"}
+	if profiled {
+		header = append(header, "Rows", "DB Hits", "Page Cache Hit Ratio", "Time (ms)")
+	}
+	rows := [][]string{}
+	var totalDbHits int64
+	var visit func(n *planNode, depth int)
+	visit = func(n *planNode, depth int) {
+		row := []string{strings.Repeat("| ", depth) + "+" + n.Operator, n.details(), ""}
+		if n.EstimatedRows != nil {
+			row[2] = strconv.FormatFloat(*n.EstimatedRows, 'f', 0, 64)
+		}
+		if profiled {
+			row = append(row,
+				strconv.FormatInt(n.Rows, 10),
+				strconv.FormatInt(n.DbHits, 10),
+				strconv.FormatFloat(n.PageCacheHitRatio, 'f', 4, 64),
+				strconv.FormatFloat(float64(n.Time)/1e6, 'f', 3, 64))
+			totalDbHits += n.DbHits
+		}
+		rows = append(rows, row)
+		for _, c := range n.Children {
+			visit(c, depth+1)
+		}
+	}
+	visit(root, 0)
+
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	var b strings.Builder
+	separator := func() {
+		for _, w := range widths {
+			b.WriteString("+" + strings.Repeat("-", w+2))
+		}
+		b.WriteString("+\n")
+	}
+	line := func(row []string, alignRight bool) {
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			// Operator tree and details are text, the rest are numbers
+			if alignRight && i > 1 {
+				cell = pad + cell
+			} else {
+				cell = cell + pad
+			}
+			b.WriteString("| " + cell + " ")
+		}
+		b.WriteString("|\n")
+	}
+	separator()
+	line(header, false)
+	separator()
+	for _, row := range rows {
+		line(row, true)
+	}
+	separator()
+	if profiled {
+		fmt.Fprintf(&b, "\nTotal database accesses: %d\n", totalDbHits)
+	}
+	return b.String()
+}
+
+func renderDOT(root *planNode) string {
+	var b strings.Builder
+	b.WriteString("digraph plan {\n")
+	b.WriteString("  node [shape=box];\n")
+	id := 0
+	var visit func(n *planNode) int
+	visit = func(n *planNode) int {
+		nodeId := id
+		id++
+		lines := []string{n.Operator}
+		if details := n.details(); details != "" {
+			lines = append(lines, details)
+		}
+		if n.EstimatedRows != nil {
+			lines = append(lines, "estimated rows: "+strconv.FormatFloat(*n.EstimatedRows, 'f', 0, 64))
+		}
+		if n.planStats != nil {
+			lines = append(lines,
+				fmt.Sprintf("rows: %d", n.Rows),
+				fmt.Sprintf("db hits: %d", n.DbHits),
+				fmt.Sprintf("page cache hit ratio: %.4f", n.PageCacheHitRatio),
+				fmt.Sprintf("time: %.3f ms", float64(n.Time)/1e6))
+		}
+		for i := range lines {
+			lines[i] = dotEscape(lines[i])
+		}
+		fmt.Fprintf(&b, "  n%d [label=\"%s\"];\n", nodeId, strings.Join(lines, `\n`))
+		for _, c := range n.Children {
+			fmt.Fprintf(&b, "  n%d -> n%d;\n", visit(c), nodeId)
+		}
+		return nodeId
+	}
+	if root != nil {
+		visit(root)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+)
+
+var renderedProfile = &profile{profile: &db.ProfiledPlan{
+	Operator:          "ProduceResults",
+	Arguments:         map[string]interface{}{"Details": "n", "EstimatedRows": 2.4},
+	Identifiers:       []string{"n"},
+	Records:           2,
+	PageCacheHitRatio: 1,
+	Time:              12000,
+	Children: []db.ProfiledPlan{
+		{
+			Operator:          "Filter",
+			Arguments:         map[string]interface{}{"Details": `n.name = "a"`, "EstimatedRows": 2.4},
+			Identifiers:       []string{"n"},
+			Records:           2,
+			DbHits:            8,
+			PageCacheHits:     3,
+			PageCacheMisses:   1,
+			PageCacheHitRatio: 0.75,
+			Time:              1500000,
+			Children: []db.ProfiledPlan{
+				{Operator: "AllNodesScan", Arguments: map[string]interface{}{"Details": "n", "EstimatedRows": 24.0}, Records: 4, DbHits: 5},
+			},
+		},
+	},
+}}
+
+func TestProfileTable(t *testing.T) {
+	expected := "" +
+		"+-------------------+--------------+----------------+------+---------+----------------------+-----------+\n" +
+		"| Operator          | Details      | Estimated Rows | Rows | DB Hits | Page Cache Hit Ratio | Time (ms) |\n" +
+		"+-------------------+--------------+----------------+------+---------+----------------------+-----------+\n" +
+		"| +ProduceResults   | n            |              2 |    2 |       0 |               1.0000 |     0.012 |\n" +
+		"| | +Filter         | n.name = \"a\" |              2 |    2 |       8 |               0.7500 |     1.500 |\n" +
+		"| | | +AllNodesScan | n            |             24 |    4 |       5 |               0.0000 |     0.000 |\n" +
+		"+-------------------+--------------+----------------+------+---------+----------------------+-----------+\n" +
+		"\n" +
+		"Total database accesses: 13\n"
+	AssertStringEqual(t, ProfileTable(renderedProfile), expected)
+}
+
+func TestPlanTable(t *testing.T) {
+	p := &plan{plan: &db.Plan{
+		Operator:  "ProduceResults",
+		Arguments: map[string]interface{}{"EstimatedRows": 1.0},
+		Children:  []db.Plan{{Operator: "Argument"}, {Operator: "AllNodesScan"}},
+	}}
+	expected := "" +
+		"+-----------------+---------+----------------+\n" +
+		"| Operator        | Details | Estimated Rows |\n" +
+		"+-----------------+---------+----------------+\n" +
+		"| +ProduceResults |         |              1 |\n" +
+		"| | +Argument     |         |                |\n" +
+		"| | +AllNodesScan |         |                |\n" +
+		"+-----------------+---------+----------------+\n"
+	AssertStringEqual(t, PlanTable(p), expected)
+	AssertStringEqual(t, PlanTable(nil), "")
+}
+
+func TestProfileJSON(t *testing.T) {
+	data, err := ProfileJSON(renderedProfile)
+	AssertNoError(t, err)
+	var tree map[string]interface{}
+	AssertNoError(t, json.Unmarshal(data, &tree))
+	AssertStringEqual(t, tree["operator"].(string), "ProduceResults")
+	filter := tree["children"].([]interface{})[0].(map[string]interface{})
+	leaf := filter["children"].([]interface{})[0].(map[string]interface{})
+	expected := map[string]interface{}{
+		"operator":          "AllNodesScan",
+		"arguments":         map[string]interface{}{"Details": "n", "EstimatedRows": 24.0},
+		"estimatedRows":     24.0,
+		"rows":              4.0,
+		"dbHits":            5.0,
+		"pageCacheHits":     0.0,
+		"pageCacheMisses":   0.0,
+		"pageCacheHitRatio": 0.0,
+		"time":              0.0,
+	}
+	if !reflect.DeepEqual(leaf, expected) {
+		t.Errorf("Expected %v but was %v", expected, leaf)
+	}
+}
+
+func TestPlanJSON(t *testing.T) {
+	data, err := PlanJSON(&plan{plan: &db.Plan{Operator: "Create", Identifiers: []string{"n"}}})
+	AssertNoError(t, err)
+	AssertStringEqual(t, string(data), `{"operator":"Create","identifiers":["n"]}`)
+}
+
+func TestProfileDOT(t *testing.T) {
+	expected := "digraph plan {\n" +
+		"  node [shape=box];\n" +
+		`  n0 [label="ProduceResults\nn\nestimated rows: 2\nrows: 2\ndb hits: 0\npage cache hit ratio: 1.0000\ntime: 0.012 ms"];` + "\n" +
+		`  n1 [label="Filter\nn.name = \"a\"\nestimated rows: 2\nrows: 2\ndb hits: 8\npage cache hit ratio: 0.7500\ntime: 1.500 ms"];` + "\n" +
+		`  n2 [label="AllNodesScan\nn\nestimated rows: 24\nrows: 4\ndb hits: 5\npage cache hit ratio: 0.0000\ntime: 0.000 ms"];` + "\n" +
+		"  n2 -> n1;\n" +
+		"  n1 -> n0;\n" +
+		"}\n"
+	AssertStringEqual(t, ProfileDOT(renderedProfile), expected)
+}
+
+func TestPlanDOT(t *testing.T) {
+	p := &plan{plan: &db.Plan{Operator: "ProduceResults", Children: []db.Plan{{Operator: "A"}, {Operator: "B"}}}}
+	expected := "digraph plan {\n" +
+		"  node [shape=box];\n" +
+		`  n0 [label="ProduceResults"];` + "\n" +
+		`  n1 [label="A"];` + "\n" +
+		"  n1 -> n0;\n" +
+		`  n2 [label="B"];` + "\n" +
+		"  n2 -> n0;\n" +
+		"}\n"
+	AssertStringEqual(t, PlanDOT(p), expected)
+}
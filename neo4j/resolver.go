@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSResolver performs the DNS lookups needed by the built-in address resolvers.
+// *net.Resolver implements this interface, custom implementations can be used to
+// query specific name servers or to avoid network access in tests.
+type DNSResolver interface {
+	// LookupHost returns the IPv4 and IPv6 addresses of the host.
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	// LookupSRV returns the SRV records of the service, when service and proto are empty
+	// name is looked up directly.
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Maximum amount of time for a single DNS lookup done by the built-in address resolvers.
+const dnsLookupTimeout = 5 * time.Second
+
+func lookupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), dnsLookupTimeout)
+}
+
+func dnsResolverOrDefault(dns DNSResolver) DNSResolver {
+	if dns == nil {
+		return net.DefaultResolver
+	}
+	return dns
+}
+
+// NewDNSAddressResolver returns an address resolver that expands the host name of the address
+// into all of its A and AAAA records, the port of the address is kept as is. If dns is nil
+// the default resolver of the net package is used. The address is returned unresolved when
+// the lookup fails. When used with an encrypted scheme, the driver connects to the IP addresses
+// but still verifies the certificates of the servers against the host name.
+//
+// The resolver is called every time the driver fails to read a routing table from all known
+// routers, DNS changes are thereby picked up when the cluster is reconfigured.
+func NewDNSAddressResolver(dns DNSResolver) ServerAddressResolver {
+	dns = dnsResolverOrDefault(dns)
+	return func(address ServerAddress) []ServerAddress {
+		ctx, cancel := lookupContext()
+		defer cancel()
+		hosts, err := dns.LookupHost(ctx, address.Hostname())
+		if err != nil || len(hosts) == 0 {
+			return []ServerAddress{address}
+		}
+		addresses := make([]ServerAddress, len(hosts))
+		for i, host := range hosts {
+			addresses[i] = &resolvedAddress{
+				ServerAddress: NewServerAddress(host, address.Port()),
+				host:          address.Hostname(),
+			}
+		}
+		return addresses
+	}
+}
+
+// NewSRVAddressResolver returns an address resolver that expands the address into the targets of
+// DNS SRV records, ordered by priority and randomized by weight. The record looked up is name,
+// for example "_neo4j._tcp.example.com". If name is empty the record "_neo4j._tcp.<host>" is
+// looked up where host is the host name of the address. If dns is nil the default resolver of
+// the net package is used. The address is returned unresolved when the lookup fails.
+//
+// The resolver is called every time the driver fails to read a routing table from all known
+// routers, DNS changes are thereby picked up when the cluster is reconfigured.
+func NewSRVAddressResolver(name string, dns DNSResolver) ServerAddressResolver {
+	dns = dnsResolverOrDefault(dns)
+	return func(address ServerAddress) []ServerAddress {
+		record := name
+		if record == "" {
+			record = "_neo4j._tcp." + address.Hostname()
+		}
+		ctx, cancel := lookupContext()
+		defer cancel()
+		_, srvs, err := dns.LookupSRV(ctx, "", "", record)
+		if err != nil || len(srvs) == 0 {
+			return []ServerAddress{address}
+		}
+		addresses := make([]ServerAddress, len(srvs))
+		for i, srv := range srvs {
+			host := strings.TrimSuffix(srv.Target, ".")
+			addresses[i] = NewServerAddress(host, strconv.Itoa(int(srv.Port)))
+		}
+		return addresses
+	}
+}
+
+// NewStaticAddressResolver returns an address resolver that always resolves to the specified
+// addresses regardless of the address that is resolved.
+func NewStaticAddressResolver(addresses ...ServerAddress) ServerAddressResolver {
+	addresses = append([]ServerAddress(nil), addresses...)
+	return func(ServerAddress) []ServerAddress {
+		return append([]ServerAddress(nil), addresses...)
+	}
+}
+
+// Address that has been resolved from a host name to an IP address.
+type resolvedAddress struct {
+	ServerAddress
+	host string
+}
+
+// Keeps track of the host names of addresses returned by NewDNSAddressResolver, the host name
+// is used to verify the certificate of the server when connecting to the IP address.
+type resolvedHosts struct {
+	mut   sync.Mutex
+	hosts map[string]string
+}
+
+func (r *resolvedHosts) add(address string, a ServerAddress) {
+	resolved, ok := a.(*resolvedAddress)
+	if !ok {
+		return
+	}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.hosts == nil {
+		r.hosts = make(map[string]string)
+	}
+	r.hosts[address] = resolved.host
+}
+
+// Returns the host name that the address was resolved from, empty if it wasn't resolved.
+func (r *resolvedHosts) host(address string) string {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.hosts[address]
+}
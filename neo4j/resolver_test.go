@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+type dnsResolverFake struct {
+	hosts   map[string][]string
+	srvs    map[string][]*net.SRV
+	lookups []string
+}
+
+func (r *dnsResolverFake) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.lookups = append(r.lookups, host)
+	hosts, exists := r.hosts[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	return hosts, nil
+}
+
+func (r *dnsResolverFake) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.lookups = append(r.lookups, name)
+	srvs, exists := r.srvs[name]
+	if !exists {
+		return "", nil, errors.New("no such host")
+	}
+	return name, srvs, nil
+}
+
+func assertAddresses(t *testing.T, addresses []ServerAddress, expected ...string) {
+	t.Helper()
+	actual := make([]string, len(addresses))
+	for i, a := range addresses {
+		actual[i] = net.JoinHostPort(a.Hostname(), a.Port())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected addresses %v but was %v", expected, actual)
+	}
+}
+
+func TestDNSAddressResolver(t *testing.T) {
+	dns := &dnsResolverFake{hosts: map[string][]string{"cluster": {"10.0.0.1", "10.0.0.2", "::1"}}}
+	resolver := NewDNSAddressResolver(dns)
+
+	t.Run("Expands all records", func(t *testing.T) {
+		addresses := resolver(NewServerAddress("cluster", "7687"))
+		assertAddresses(t, addresses, "10.0.0.1:7687", "10.0.0.2:7687", "[::1]:7687")
+	})
+
+	t.Run("Returns address on failure", func(t *testing.T) {
+		addresses := resolver(NewServerAddress("unknown", "7687"))
+		assertAddresses(t, addresses, "unknown:7687")
+	})
+
+	t.Run("Keeps host name for verification", func(t *testing.T) {
+		hosts := &resolvedHosts{}
+		for _, a := range resolver(NewServerAddress("cluster", "7687")) {
+			hosts.add(net.JoinHostPort(a.Hostname(), a.Port()), a)
+		}
+		hosts.add("other:7687", NewServerAddress("other", "7687"))
+		for address, expected := range map[string]string{"10.0.0.2:7687": "cluster", "[::1]:7687": "cluster", "other:7687": ""} {
+			if host := hosts.host(address); host != expected {
+				t.Errorf("Expected host name of %s to be '%s' but was '%s'", address, expected, host)
+			}
+		}
+	})
+
+	t.Run("Looks up every time", func(t *testing.T) {
+		dns.lookups = nil
+		resolver(NewServerAddress("cluster", "7687"))
+		resolver(NewServerAddress("cluster", "7687"))
+		if !reflect.DeepEqual(dns.lookups, []string{"cluster", "cluster"}) {
+			t.Errorf("Unexpected lookups %v", dns.lookups)
+		}
+	})
+}
+
+func TestSRVAddressResolver(t *testing.T) {
+	dns := &dnsResolverFake{srvs: map[string][]*net.SRV{
+		"_neo4j._tcp.cluster": {{Target: "core1.cluster.", Port: 7687}, {Target: "core2.cluster.", Port: 7688}},
+		"_custom._tcp.other":  {{Target: "core3.other.", Port: 7689}},
+	}}
+
+	t.Run("Default record name", func(t *testing.T) {
+		addresses := NewSRVAddressResolver("", dns)(NewServerAddress("cluster", "7687"))
+		assertAddresses(t, addresses, "core1.cluster:7687", "core2.cluster:7688")
+	})
+
+	t.Run("Custom record name", func(t *testing.T) {
+		addresses := NewSRVAddressResolver("_custom._tcp.other", dns)(NewServerAddress("cluster", "7687"))
+		assertAddresses(t, addresses, "core3.other:7689")
+	})
+
+	t.Run("Returns address on failure", func(t *testing.T) {
+		addresses := NewSRVAddressResolver("", dns)(NewServerAddress("unknown", "7687"))
+		assertAddresses(t, addresses, "unknown:7687")
+	})
+}
+
+func TestStaticAddressResolver(t *testing.T) {
+	static := []ServerAddress{NewServerAddress("a", "1"), NewServerAddress("b", "2")}
+	resolver := NewStaticAddressResolver(static...)
+	static[0] = NewServerAddress("c", "3")
+
+	addresses := resolver(NewServerAddress("cluster", "7687"))
+	assertAddresses(t, addresses, "a:1", "b:2")
+}
@@ -53,6 +53,12 @@ type result struct {
 	record       *Record
 	summary      *db.Summary
 	err          error
+	// Handles the first error received from the connection
+	onError func(error)
+	// Set when warning notifications should be logged
+	notifications *notificationLogger
+	// Set when slow queries should be logged
+	slowQueries *slowQueryLog
 }
 
 func newResult(conn db.Connection, str db.StreamHandle, cypher string, params map[string]interface{}) *result {
@@ -65,16 +71,22 @@ func newResult(conn db.Connection, str db.StreamHandle, cypher string, params ma
 }
 
 func (r *result) Keys() ([]string, error) {
-	return r.conn.Keys(r.streamHandle)
+	keys, err := r.conn.Keys(r.streamHandle)
+	r.handleError(err)
+	return keys, err
 }
 
 func (r *result) Next() bool {
 	r.record, r.summary, r.err = r.conn.Next(r.streamHandle)
+	r.handleError(r.err)
+	r.logSummary(r.summary)
 	return r.record != nil
 }
 
 func (r *result) NextRecord(out **Record) bool {
 	r.record, r.summary, r.err = r.conn.Next(r.streamHandle)
+	r.handleError(r.err)
+	r.logSummary(r.summary)
 	if out != nil {
 		*out = r.record
 	}
@@ -97,6 +109,8 @@ func (r *result) Collect() ([]*Record, error) {
 			recs = append(recs, r.record)
 		}
 	}
+	r.handleError(r.err)
+	r.logSummary(r.summary)
 	if r.err != nil {
 		return nil, wrapError(r.err)
 	}
@@ -105,11 +119,17 @@ func (r *result) Collect() ([]*Record, error) {
 
 func (r *result) buffer() {
 	r.err = r.conn.Buffer(r.streamHandle)
+	r.handleError(r.err)
+	if r.err == nil {
+		r.logPending()
+	}
 }
 
 func (r *result) Single() (*Record, error) {
 	// Try retrieving the single record
 	r.record, r.summary, r.err = r.conn.Next(r.streamHandle)
+	r.handleError(r.err)
+	r.logSummary(r.summary)
 	if r.err != nil {
 		return nil, wrapError(r.err)
 	}
@@ -126,11 +146,16 @@ func (r *result) Single() (*Record, error) {
 	if r.record != nil {
 		// There were more records, consume the stream since the user didn't
 		// expect more records and should therefore not use them.
-		r.summary, _ = r.conn.Consume(r.streamHandle)
+		var err error
+		r.summary, err = r.conn.Consume(r.streamHandle)
+		r.handleError(err)
+		r.logSummary(r.summary)
 		r.err = &UsageError{Message: "Result contains more than one record"}
 		r.record = nil
 		return nil, r.err
 	}
+	r.handleError(r.err)
+	r.logSummary(r.summary)
 	if r.err != nil {
 		// Might be more records or not, anyway something is bad.
 		// Both r.record and r.summary are nil at this point which is good.
@@ -160,8 +185,65 @@ func (r *result) Consume() (ResultSummary, error) {
 
 	r.record = nil
 	r.summary, r.err = r.conn.Consume(r.streamHandle)
+	r.handleError(r.err)
 	if r.err != nil {
 		return nil, wrapError(r.err)
 	}
+	r.logSummary(r.summary)
 	return r.toResultSummary(), nil
 }
+
+// Handles the first error received from the connection, see session.onError, and reports the
+// failed query when it was slow.
+func (r *result) handleError(err error) {
+	if err == nil {
+		return
+	}
+	if r.onError != nil {
+		r.onError(err)
+		r.onError = nil
+	}
+	if r.slowQueries != nil {
+		r.slowQueries.check(r.cypher, r.params, r.conn.ServerName(), nil, err)
+		r.slowQueries = nil
+	}
+}
+
+// Returns true if the summary is still needed to log warning notifications or slow queries.
+func (r *result) logging() bool {
+	return r.notifications != nil || r.slowQueries != nil
+}
+
+// Logs warning notifications and slow queries once when the summary has been received.
+func (r *result) logSummary(summary *db.Summary) {
+	if summary == nil {
+		return
+	}
+	if r.notifications != nil {
+		r.notifications.logWarnings(r.cypher, summary)
+		r.notifications = nil
+	}
+	if r.slowQueries != nil {
+		r.slowQueries.check(r.cypher, r.params, r.conn.ServerName(), summary, nil)
+		r.slowQueries = nil
+	}
+}
+
+// Logs the summary of a result that hasn't been consumed when it is needed for logging. Called
+// when the records have been buffered, the summary is then received without discarding them,
+// or when the transaction is about to end and discard the remaining records anyway.
+func (r *result) logPending() {
+	if r.err != nil || !r.logging() {
+		return
+	}
+	summary, err := r.conn.Consume(r.streamHandle)
+	r.handleError(err)
+	r.logSummary(summary)
+}
+
+// Logs the results of a transaction that haven't been consumed, see logPending.
+func logPendingResults(results []*result) {
+	for _, r := range results {
+		r.logPending()
+	}
+}
@@ -205,6 +205,12 @@ type Notification interface {
 	Position() InputPosition
 	// Severity returns the severity level of this notification.
 	Severity() string
+	// SeverityLevel returns the severity level of this notification as one of the
+	// NotificationSeverity constants.
+	SeverityLevel() NotificationSeverity
+	// Category returns the category of this notification as sent by the server or, for servers
+	// that don't send categories, as derived from the code of well known notifications.
+	Category() NotificationCategory
 }
 
 // InputPosition contains information about a specific position in a statement
@@ -390,7 +396,8 @@ func (p *plan) Identifiers() []string {
 func (p *plan) Children() []Plan {
 	children := make([]Plan, len(p.plan.Children))
 	for i, c := range p.plan.Children {
-		children[i] = &plan{plan: &c}
+		child := c
+		children[i] = &plan{plan: &child}
 	}
 	return children
 }
@@ -486,6 +493,14 @@ func (n *notification) Severity() string {
 	return n.notification.Severity
 }
 
+func (n *notification) SeverityLevel() NotificationSeverity {
+	return notificationSeverity(n.notification.Severity)
+}
+
+func (n *notification) Category() NotificationCategory {
+	return notificationCategory(n.notification.Category, n.notification.Code)
+}
+
 func (n *notification) Position() InputPosition {
 	if n.notification.Position == nil {
 		return nil
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package retrypolicy defines how the driver retries transaction functions that
+// fail, for example due to a lost connection or a cluster leader switch.
+package retrypolicy
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+)
+
+// Causes of failures that the driver classifies as retryable.
+const (
+	// CauseNoConnection means that no connection to a server could be acquired.
+	CauseNoConnection = "No available connection"
+	// CauseConnectionLost means that the connection was lost during the transaction.
+	CauseConnectionLost = "Connection lost"
+	// CauseClusterError means that the server could not execute the transaction due
+	// to its role in the cluster, the routing table is refreshed before retrying.
+	CauseClusterError = "Cluster error"
+	// CauseTransientError means that the server reported a transient error.
+	CauseTransientError = "Transient error"
+	// CauseRetryableCode means that the server reported an error with a code that
+	// has been configured as retryable, see WithRetryableCodes.
+	CauseRetryableCode = "Retryable code"
+	// CauseTokenExpired means that the auth token expired and that the driver has an
+	// auth token manager that can provide a new one.
+	CauseTokenExpired = "Token expired"
+)
+
+// Attempt describes a failed attempt to execute a transaction function.
+type Attempt struct {
+	// Number of the attempt, 1 for the first attempt.
+	Number int
+	// Elapsed is the time since the first attempt was started.
+	Elapsed time.Duration
+	// Err is the error that caused the attempt to fail.
+	Err error
+	// Cause is one of the Cause constants when the driver considers the error to be
+	// retryable, empty otherwise.
+	Cause string
+	// Retryable is true when the driver considers the error to be retryable.
+	Retryable bool
+}
+
+// Policy decides whether a failed transaction function should be retried and
+// for how long to wait before retrying. Errors that happen while committing on
+// a connection that is lost are never retried since the transaction might have
+// been committed.
+//
+// Decide is called concurrently from multiple goroutines.
+type Policy interface {
+	Decide(attempt Attempt) (retry bool, delay time.Duration)
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as policies.
+type PolicyFunc func(attempt Attempt) (bool, time.Duration)
+
+// Decide calls f(attempt).
+func (f PolicyFunc) Decide(attempt Attempt) (bool, time.Duration) {
+	return f(attempt)
+}
+
+// ExponentialBackoff returns a policy that retries retryable errors until
+// maxRetryTime has elapsed since the first attempt. The delay starts at
+// initialDelay, is doubled for every attempt up to maxDelay and is randomized
+// by 20% in either direction. A maxDelay less than or equal to 0 means that the
+// delay is not limited. Lost connections are retried without delay.
+func ExponentialBackoff(initialDelay, maxDelay, maxRetryTime time.Duration) Policy {
+	return &exponentialBackoff{initialDelay: initialDelay, maxDelay: maxDelay, maxRetryTime: maxRetryTime}
+}
+
+// MaxAttempts returns a policy that retries retryable errors with a fixed delay
+// until the transaction function has been attempted the specified number of
+// times.
+func MaxAttempts(attempts int, delay time.Duration) Policy {
+	return &maxAttempts{attempts: attempts, delay: delay}
+}
+
+// NoRetry returns a policy that never retries, the error of the transaction function is
+// returned as is.
+func NoRetry() Policy {
+	return PolicyFunc(func(Attempt) (bool, time.Duration) {
+		return false, 0
+	})
+}
+
+// WithRetryableCodes returns a policy that considers server errors with any of
+// the specified codes, for example "Neo.ClientError.Transaction.LockClientStopped",
+// to be retryable in addition to the errors the driver considers retryable, also
+// when wrapped by the transaction function. The decision is delegated to the
+// specified policy.
+func WithRetryableCodes(policy Policy, codes ...string) Policy {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return PolicyFunc(func(attempt Attempt) (bool, time.Duration) {
+		var dbErr *db.Neo4jError
+		if !attempt.Retryable && errors.As(attempt.Err, &dbErr) && set[dbErr.Code] {
+			attempt.Retryable = true
+			attempt.Cause = CauseRetryableCode
+		}
+		return policy.Decide(attempt)
+	})
+}
+
+type exponentialBackoff struct {
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	maxRetryTime time.Duration
+}
+
+func (p *exponentialBackoff) Decide(attempt Attempt) (bool, time.Duration) {
+	if !attempt.Retryable || attempt.Elapsed > p.maxRetryTime {
+		return false, 0
+	}
+	if attempt.Cause == CauseConnectionLost {
+		return true, 0
+	}
+	const delayJitter = 0.2
+	// Limit the exponent to avoid overflow when there is no max delay
+	exp := math.Min(float64(attempt.Number-1), 30)
+	delay := float64(p.initialDelay) * math.Pow(2, exp)
+	if p.maxDelay > 0 && delay > float64(p.maxDelay) {
+		delay = float64(p.maxDelay)
+	}
+	jitter := delay * delayJitter
+	return true, time.Duration(delay - jitter + 2*jitter*rand.Float64())
+}
+
+type maxAttempts struct {
+	attempts int
+	delay    time.Duration
+}
+
+func (p *maxAttempts) Decide(attempt Attempt) (bool, time.Duration) {
+	if !attempt.Retryable || attempt.Number >= p.attempts {
+		return false, 0
+	}
+	return true, p.delay
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package retrypolicy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+)
+
+type decision struct {
+	attempt Attempt
+	retry   bool
+	min     time.Duration
+	max     time.Duration
+}
+
+func assertDecisions(t *testing.T, policy Policy, decisions []decision) {
+	t.Helper()
+	for _, d := range decisions {
+		retry, delay := policy.Decide(d.attempt)
+		if retry != d.retry {
+			t.Errorf("Expected retry to be %t for %+v", d.retry, d.attempt)
+		}
+		if delay < d.min || delay > d.max {
+			t.Errorf("Expected delay between %s and %s for %+v but was %s", d.min, d.max, d.attempt, delay)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	policy := ExponentialBackoff(time.Second, 5*time.Second, time.Minute)
+	assertDecisions(t, policy, []decision{
+		{attempt: Attempt{Number: 1, Retryable: true}, retry: true, min: 800 * time.Millisecond, max: 1200 * time.Millisecond},
+		{attempt: Attempt{Number: 2, Retryable: true}, retry: true, min: 1600 * time.Millisecond, max: 2400 * time.Millisecond},
+		{attempt: Attempt{Number: 3, Retryable: true}, retry: true, min: 3200 * time.Millisecond, max: 4800 * time.Millisecond},
+		{attempt: Attempt{Number: 10, Retryable: true}, retry: true, min: 4 * time.Second, max: 6 * time.Second},
+		{attempt: Attempt{Number: 2, Retryable: true, Cause: CauseConnectionLost}, retry: true},
+		{attempt: Attempt{Number: 2, Retryable: true, Elapsed: 2 * time.Minute}, retry: false},
+		{attempt: Attempt{Number: 1, Retryable: false}, retry: false},
+	})
+
+	t.Run("Unlimited delay", func(t *testing.T) {
+		policy := ExponentialBackoff(time.Second, 0, time.Minute)
+		retry, delay := policy.Decide(Attempt{Number: 1000, Retryable: true})
+		if !retry || delay <= 0 {
+			t.Errorf("Expected retry with positive delay but was %t, %s", retry, delay)
+		}
+	})
+}
+
+func TestMaxAttempts(t *testing.T) {
+	policy := MaxAttempts(3, time.Second)
+	assertDecisions(t, policy, []decision{
+		{attempt: Attempt{Number: 1, Retryable: true}, retry: true, min: time.Second, max: time.Second},
+		{attempt: Attempt{Number: 2, Retryable: true, Elapsed: time.Hour}, retry: true, min: time.Second, max: time.Second},
+		{attempt: Attempt{Number: 3, Retryable: true}, retry: false},
+		{attempt: Attempt{Number: 1, Retryable: false}, retry: false},
+	})
+}
+
+func TestNoRetry(t *testing.T) {
+	assertDecisions(t, NoRetry(), []decision{
+		{attempt: Attempt{Number: 1, Retryable: true}, retry: false},
+	})
+}
+
+func TestWithRetryableCodes(t *testing.T) {
+	var decided Attempt
+	policy := WithRetryableCodes(PolicyFunc(func(a Attempt) (bool, time.Duration) {
+		decided = a
+		return a.Retryable, 0
+	}), "Neo.ClientError.Transaction.LockClientStopped")
+
+	assertDecisions(t, policy, []decision{
+		{attempt: Attempt{Number: 1, Err: &db.Neo4jError{Code: "Neo.ClientError.Transaction.LockClientStopped"}}, retry: true},
+	})
+	if decided.Cause != CauseRetryableCode {
+		t.Errorf("Expected cause to be set but was '%s'", decided.Cause)
+	}
+	wrapped := fmt.Errorf("creating order: %w", &db.Neo4jError{Code: "Neo.ClientError.Transaction.LockClientStopped"})
+	assertDecisions(t, policy, []decision{
+		{attempt: Attempt{Number: 1, Err: wrapped}, retry: true},
+	})
+	assertDecisions(t, policy, []decision{
+		{attempt: Attempt{Number: 1, Err: &db.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"}}, retry: false},
+		{attempt: Attempt{Number: 1, Err: errors.New("Neo.ClientError.Transaction.LockClientStopped")}, retry: false},
+	})
+	// Already retryable errors should keep their cause
+	policy.Decide(Attempt{Number: 1, Retryable: true, Cause: CauseClusterError})
+	if decided.Cause != CauseClusterError {
+		t.Errorf("Expected cause to be kept but was '%s'", decided.Cause)
+	}
+}
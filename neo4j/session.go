@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/retry"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
 )
@@ -94,6 +95,16 @@ type SessionConfig struct {
 	// to the correct cluster member (different databases may have different
 	// leaders).
 	ImpersonatedUser string
+	// DefaultTxMetadata is attached to all transactions of the session, auto-commit and
+	// explicit transactions as well as transaction functions. It is merged with
+	// Config.DefaultTxMetadata and with the metadata given by WithTxMetadata, keys given for a
+	// transaction take precedence over keys of the session which take precedence over keys of
+	// the driver.
+	DefaultTxMetadata map[string]interface{}
+	// DefaultTxTimeout is the timeout of all transactions of the session that are not given a
+	// timeout by WithTxTimeout. If not set, Config.DefaultTxTimeout is used. It cannot be
+	// specified as a negative value.
+	DefaultTxTimeout time.Duration
 }
 
 // FetchAll turns off fetching records in batches.
@@ -106,6 +117,8 @@ const FetchDefault = 0
 type sessionPool interface {
 	Borrow(ctx context.Context, serverNames []string, wait bool, boltLogger log.BoltLogger) (db.Connection, error)
 	Return(c db.Connection)
+	// Reports that work on a borrowed connection failed, called before the connection is returned.
+	Failed(c db.Connection, err error)
 	CleanUp()
 }
 
@@ -124,9 +137,13 @@ type session struct {
 	now              func() time.Time
 	logId            string
 	log              log.Logger
+	listener         event.Listener
+	auth             *driverAuth
 	throttleTime     time.Duration
 	fetchSize        int
 	boltLogger       log.BoltLogger
+	txMetadata       map[string]interface{}
+	txTimeout        time.Duration
 }
 
 // Remove empty string bookmarks to check for "bad" callers
@@ -153,7 +170,7 @@ func cleanupBookmarks(bookmarks []string) []string {
 	return cleaned
 }
 
-func newSession(config *Config, sessConfig SessionConfig, router sessionRouter, pool sessionPool, logger log.Logger) *session {
+func newSession(config *Config, sessConfig SessionConfig, router sessionRouter, pool sessionPool, logger log.Logger, listener event.Listener, auth *driverAuth) *session {
 	logId := log.NewId()
 	logger.Debugf(log.Session, logId, "Created")
 
@@ -162,6 +179,11 @@ func newSession(config *Config, sessConfig SessionConfig, router sessionRouter,
 		fetchSize = sessConfig.FetchSize
 	}
 
+	txTimeout := config.DefaultTxTimeout
+	if sessConfig.DefaultTxTimeout != 0 {
+		txTimeout = sessConfig.DefaultTxTimeout
+	}
+
 	return &session{
 		config:           config,
 		router:           router,
@@ -175,10 +197,45 @@ func newSession(config *Config, sessConfig SessionConfig, router sessionRouter,
 		now:              time.Now,
 		log:              logger,
 		logId:            logId,
+		listener:         listener,
+		auth:             auth,
 		throttleTime:     time.Second * 1,
 		fetchSize:        fetchSize,
 		boltLogger:       sessConfig.BoltLogger,
+		txMetadata:       mergeTxMetadata(config.DefaultTxMetadata, sessConfig.DefaultTxMetadata),
+		txTimeout:        txTimeout,
+	}
+}
+
+// Returns metadata with keys in defaults that aren't in metadata added.
+func mergeTxMetadata(defaults, metadata map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return metadata
+	}
+	if len(metadata) == 0 {
+		return defaults
+	}
+	merged := make(map[string]interface{}, len(defaults)+len(metadata))
+	for k, v := range defaults {
+		merged[k] = v
 	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Applies the configuration functions on top of the defaults of the session.
+func (s *session) transactionConfig(configurers []func(*TransactionConfig)) TransactionConfig {
+	config := TransactionConfig{Timeout: 0, Metadata: nil}
+	for _, c := range configurers {
+		c(&config)
+	}
+	if config.Timeout == 0 && !config.timeoutSet {
+		config.Timeout = s.txTimeout
+	}
+	config.Metadata = mergeTxMetadata(s.txMetadata, config.Metadata)
+	return config
 }
 
 func (s *session) LastBookmark() string {
@@ -208,10 +265,7 @@ func (s *session) BeginTransaction(configurers ...func(*TransactionConfig)) (Tra
 	}
 
 	// Apply configuration functions
-	config := TransactionConfig{Timeout: 0, Metadata: nil}
-	for _, c := range configurers {
-		c(&config)
-	}
+	config := s.transactionConfig(configurers)
 
 	// Get a connection from the pool. This could fail in clustered environment.
 	conn, err := s.getConnection(s.defaultMode)
@@ -228,15 +282,20 @@ func (s *session) BeginTransaction(configurers ...func(*TransactionConfig)) (Tra
 		ImpersonatedUser: s.impersonatedUser,
 	})
 	if err != nil {
+		s.pool.Failed(conn, err)
 		s.pool.Return(conn)
-		return nil, wrapError(err)
+		return nil, s.wrapError(err)
 	}
 
 	// Create transaction wrapper
 	s.txExplicit = &transaction{
-		conn:      conn,
-		fetchSize: s.fetchSize,
-		txHandle:  txHandle,
+		conn:          conn,
+		fetchSize:     s.fetchSize,
+		prefetchRatio: s.config.PrefetchRatio,
+		txHandle:      txHandle,
+		onError:       s.connErrorHandler(conn),
+		notifications: s.notificationLogger(),
+		slowQueries:   s.slowQueryLog(1, 0),
 		onClosed: func() {
 			// On transaction closed (rollbacked or committed)
 			s.retrieveBookmarks(conn)
@@ -262,10 +321,7 @@ func (s *session) runRetriable(
 		s.txAuto.done()
 	}
 
-	config := TransactionConfig{Timeout: 0, Metadata: nil}
-	for _, c := range configurers {
-		c(&config)
-	}
+	config := s.transactionConfig(configurers)
 
 	state := retry.State{
 		MaxTransactionRetryTime: s.config.MaxTransactionRetryTime,
@@ -278,9 +334,23 @@ func (s *session) runRetriable(
 		MaxDeadConnections:      s.config.MaxConnectionPoolSize,
 		Router:                  s.router,
 		DatabaseName:            s.databaseName,
+		Policy:                  s.config.RetryPolicy,
+	}
+	if config.RetryPolicy != nil {
+		state.Policy = config.RetryPolicy
 	}
+	if s.auth.rotating() {
+		state.TokenExpired = isTokenExpired
+	}
+	start := s.now()
+	attempt := 0
 	for state.Continue() {
-		if workResult, successfullyCompleted := s.tryRun(&state, mode, &config, work); successfullyCompleted {
+		attempt++
+		var retryTime time.Duration
+		if attempt > 1 {
+			retryTime = s.now().Sub(start)
+		}
+		if workResult, successfullyCompleted := s.tryRun(&state, mode, &config, work, attempt, retryTime); successfullyCompleted {
 			return workResult, nil
 		}
 	}
@@ -292,7 +362,7 @@ func (s *session) runRetriable(
 		s.log.Error(log.Session, s.logId, err)
 		return nil, err
 	}
-	// Wrap and log the error if it belongs to the driver
+	// Wrap and log the error if it belongs to the driver, the error has already been handled
 	err := wrapError(state.LastErr)
 	switch err.(type) {
 	case *UsageError, *ConnectivityError:
@@ -301,7 +371,9 @@ func (s *session) runRetriable(
 	return nil, err
 }
 
-func (s *session) tryRun(state *retry.State, mode db.AccessMode, config *TransactionConfig, work TransactionWork) (interface{}, bool) {
+func (s *session) tryRun(
+	state *retry.State, mode db.AccessMode, config *TransactionConfig, work TransactionWork,
+	attempt int, retryTime time.Duration) (interface{}, bool) {
 	conn, err := s.getConnection(mode)
 	if err != nil {
 		state.OnFailure(conn, err, false)
@@ -309,6 +381,7 @@ func (s *session) tryRun(state *retry.State, mode db.AccessMode, config *Transac
 	}
 
 	defer s.pool.Return(conn)
+	onError := s.connErrorHandler(conn)
 	txHandle, err := conn.TxBegin(db.TxConfig{
 		Mode:             mode,
 		Bookmarks:        s.bookmarks,
@@ -317,15 +390,32 @@ func (s *session) tryRun(state *retry.State, mode db.AccessMode, config *Transac
 		ImpersonatedUser: s.impersonatedUser,
 	})
 	if err != nil {
+		onError(err)
 		state.OnFailure(conn, err, false)
 		return nil, false
 	}
 
-	tx := retryableTransaction{conn: conn, fetchSize: s.fetchSize, txHandle: txHandle}
+	handled := false
+	tx := retryableTransaction{
+		conn:          conn,
+		fetchSize:     s.fetchSize,
+		prefetchRatio: s.config.PrefetchRatio,
+		txHandle:      txHandle,
+		onError: func(err error) {
+			handled = true
+			onError(err)
+		},
+		notifications: s.notificationLogger(),
+		slowQueries:   s.slowQueryLog(attempt, retryTime),
+	}
 	x, err := work(&tx)
 	// Evaluate the returned error from all the work for retryable, this means
 	// that client can mess up the error handling.
 	if err != nil {
+		// Errors received from the connection have already been handled
+		if !handled {
+			s.onError(err)
+		}
 		// If the client returns a client specific error that means that
 		// client wants to rollback. We don't do an explicit rollback here
 		// but instead realy on pool invoking reset on the connection, that
@@ -334,8 +424,10 @@ func (s *session) tryRun(state *retry.State, mode db.AccessMode, config *Transac
 		return nil, false
 	}
 
+	logPendingResults(tx.results)
 	err = conn.TxCommit(txHandle)
 	if err != nil {
+		onError(err)
 		state.OnFailure(conn, err, true)
 		return nil, false
 	}
@@ -380,7 +472,7 @@ func (s *session) getConnection(mode db.AccessMode) (db.Connection, error) {
 	if s.getDefaultDbName {
 		defaultDb, err := s.router.GetNameOfDefaultDatabase(ctx, s.bookmarks, s.impersonatedUser, s.boltLogger)
 		if err != nil {
-			return nil, wrapError(err)
+			return nil, s.wrapError(err)
 		}
 		s.log.Debugf(log.Session, s.logId, "Retrieved default database for impersonated user, uses db '%s'", defaultDb)
 		s.databaseName = defaultDb
@@ -388,12 +480,18 @@ func (s *session) getConnection(mode db.AccessMode) (db.Connection, error) {
 	}
 	servers, err := s.getServers(ctx, mode)
 	if err != nil {
-		return nil, wrapError(err)
+		return nil, s.wrapError(err)
 	}
 
 	conn, err := s.pool.Borrow(ctx, servers, s.config.ConnectionAcquisitionTimeout != 0, s.boltLogger)
+	if err != nil && isTokenExpired(err) && s.auth.rotating() {
+		// Nothing has been executed yet, safe to try again with a new token
+		s.onError(err)
+		s.log.Infof(log.Session, s.logId, "Retrying to acquire connection with new auth token")
+		conn, err = s.pool.Borrow(ctx, servers, s.config.ConnectionAcquisitionTimeout != 0, s.boltLogger)
+	}
 	if err != nil {
-		return nil, wrapError(err)
+		return nil, s.wrapError(err)
 	}
 
 	// Select database on server
@@ -409,6 +507,35 @@ func (s *session) getConnection(mode db.AccessMode) (db.Connection, error) {
 	return conn, nil
 }
 
+// Wraps the error and handles it, see onError.
+func (s *session) wrapError(err error) error {
+	s.onError(err)
+	return wrapError(err)
+}
+
+// Notifies the listener and the auth token manager if the error indicates that the auth token
+// has expired. Forgets about the database if the server reports that it doesn't exist, this
+// forces the home database to be resolved again.
+func (s *session) onError(err error) {
+	if expired, isExpired := asTokenExpired(err); isExpired {
+		s.listener.OnEvent(event.TokenExpired{Code: expired.Code, Message: expired.Message})
+		s.auth.onTokenExpired()
+		return
+	}
+	if e, isDbErr := err.(*db.Neo4jError); isDbErr && e.Code == "Neo.ClientError.Database.DatabaseNotFound" {
+		s.router.Invalidate(s.databaseName)
+	}
+}
+
+// Returns the handler of errors from work on conn, reports the failure to the pool before
+// handling it like onError.
+func (s *session) connErrorHandler(conn db.Connection) func(error) {
+	return func(err error) {
+		s.pool.Failed(conn, err)
+		s.onError(err)
+	}
+}
+
 func (s *session) retrieveBookmarks(conn db.Connection) {
 	if conn == nil {
 		return
@@ -428,15 +555,20 @@ func (s *session) Run(
 		return nil, err
 	}
 
+	if err := ValidateParameters(params); err != nil {
+		s.log.Error(log.Session, s.logId, err)
+		return nil, err
+	}
+
 	if s.txAuto != nil {
 		s.txAuto.done()
 	}
 
-	config := TransactionConfig{Timeout: 0, Metadata: nil}
-	for _, c := range configurers {
-		c(&config)
-	}
+	config := s.transactionConfig(configurers)
 
+	// Wall time includes waiting for a connection, the log is built after the connection has
+	// been acquired since that resolves the home database.
+	start := s.now()
 	var (
 		conn db.Connection
 		err  error
@@ -444,6 +576,7 @@ func (s *session) Run(
 	for {
 		conn, err = s.getConnection(s.defaultMode)
 		if err != nil {
+			s.slowQueryLog(1, 0).startQueryAt(start).check(cypher, params, "", nil, err)
 			return nil, err
 		}
 		err = conn.ForceReset()
@@ -451,12 +584,14 @@ func (s *session) Run(
 			break
 		}
 	}
+	query := s.slowQueryLog(1, 0).startQueryAt(start)
 
 	stream, err := conn.Run(
 		db.Command{
-			Cypher:    cypher,
-			Params:    params,
-			FetchSize: s.fetchSize,
+			Cypher:        cypher,
+			Params:        params,
+			FetchSize:     s.fetchSize,
+			PrefetchRatio: s.config.PrefetchRatio,
 		},
 		db.TxConfig{
 			Mode:             s.defaultMode,
@@ -466,13 +601,19 @@ func (s *session) Run(
 			ImpersonatedUser: s.impersonatedUser,
 		})
 	if err != nil {
+		query.check(cypher, params, conn.ServerName(), nil, err)
+		s.pool.Failed(conn, err)
 		s.pool.Return(conn)
-		return nil, wrapError(err)
+		return nil, s.wrapError(err)
 	}
 
+	res := newResult(conn, stream, cypher, params)
+	res.onError = s.connErrorHandler(conn)
+	res.notifications = s.notificationLogger()
+	res.slowQueries = query
 	s.txAuto = &autoTransaction{
 		conn: conn,
-		res:  newResult(conn, stream, cypher, params),
+		res:  res,
 		onClosed: func() {
 			s.retrieveBookmarks(conn)
 			s.pool.Return(conn)
@@ -483,6 +624,35 @@ func (s *session) Run(
 	return s.txAuto.res, nil
 }
 
+// Returns the logger of warning notifications or nil when they should not be logged.
+func (s *session) notificationLogger() *notificationLogger {
+	if !s.config.LogNotifications {
+		return nil
+	}
+	return &notificationLogger{log: s.log, logId: s.logId}
+}
+
+// Returns the measurement of slow queries run in the attempt of a transaction function, 1 when
+// not in a transaction function, after retryTime spent on previous attempts. Nil when slow
+// queries should not be logged.
+func (s *session) slowQueryLog(attempt int, retryTime time.Duration) *slowQueryLog {
+	if s.config.SlowQueryThreshold <= 0 {
+		return nil
+	}
+	logger := s.config.SlowQueryLogger
+	if logger == nil {
+		logger = &slowQueryWarner{log: s.log, logId: s.logId}
+	}
+	return &slowQueryLog{
+		threshold: s.config.SlowQueryThreshold,
+		logger:    logger,
+		now:       s.now,
+		attempt:   attempt,
+		retryTime: retryTime,
+		database:  s.databaseName,
+	}
+}
+
 func (s *session) Close() error {
 	var err error
 
@@ -29,9 +29,11 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/retry"
 	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/retrypolicy"
 )
 
 func TestSession(st *testing.T) {
@@ -49,7 +51,7 @@ func TestSession(st *testing.T) {
 		router := RouterFake{}
 		pool := PoolFake{}
 		sessConfig := SessionConfig{AccessMode: AccessModeRead, BoltLogger: &boltLogger}
-		sess := newSession(&conf, sessConfig, &router, &pool, &logger)
+		sess := newSession(&conf, sessConfig, &router, &pool, &logger, &event.Void{}, nil)
 		sess.throttleTime = time.Millisecond * 1
 		return &router, &pool, sess
 	}
@@ -58,7 +60,7 @@ func TestSession(st *testing.T) {
 		conf := Config{MaxTransactionRetryTime: 3 * time.Millisecond}
 		router := RouterFake{}
 		pool := PoolFake{}
-		sess := newSession(&conf, sessConfig, &router, &pool, &logger)
+		sess := newSession(&conf, sessConfig, &router, &pool, &logger, &event.Void{}, nil)
 		sess.throttleTime = time.Millisecond * 1
 		return &router, &pool, sess
 	}
@@ -138,6 +140,79 @@ func TestSession(st *testing.T) {
 			assertCleanSessionState(t, sess)
 		})
 
+		rt.Run("Retry policy", func(t *testing.T) {
+			_, pool, sess := createSession()
+			sess.config.RetryPolicy = retrypolicy.MaxAttempts(3, 0)
+			pool.BorrowConn = &ConnFake{Alive: true}
+			transientErr := &db.Neo4jError{Code: "Neo.TransientError.General.MemoryPoolOutOfMemoryError"}
+			numRetries := 0
+			work := func(tx Transaction) (interface{}, error) {
+				numRetries++
+				return nil, transientErr
+			}
+
+			_, err := sess.WriteTransaction(work)
+			AssertIntEqual(t, numRetries, 3)
+			AssertTrue(t, IsTransactionExecutionLimit(err))
+
+			// Override per transaction function
+			numRetries = 0
+			_, err = sess.WriteTransaction(work, WithTxRetryPolicy(retrypolicy.NoRetry()))
+			AssertIntEqual(t, numRetries, 1)
+			assertErrorEq(t, transientErr, err)
+			assertCleanSessionState(t, sess)
+		})
+
+		rt.Run("Token expiration with auth token manager", func(tt *testing.T) {
+			// The work returns the errors as returned by the transaction and the result
+			failures := map[string]func(conn *ConnFake, err error){
+				"Run":     func(conn *ConnFake, err error) { conn.RunTxErr = err },
+				"Consume": func(conn *ConnFake, err error) { conn.ConsumeErr = err },
+			}
+			for name, fail := range failures {
+				tt.Run(name, func(t *testing.T) {
+					_, pool, sess := createSession()
+					manager := &authTokenManagerFake{tokens: []AuthToken{BearerAuth("first"), BearerAuth("second")}}
+					sess.auth = newManagedAuth(manager)
+					listener := &ListenerFake{}
+					sess.listener = listener
+					conn := &ConnFake{Alive: true}
+					fail(conn, tokenExpiredErr)
+					pool.BorrowConn = conn
+					numRetries := 0
+					work := func(tx Transaction) (interface{}, error) {
+						numRetries++
+						if numRetries > 1 {
+							fail(conn, nil)
+						}
+						result, err := tx.Run("cypher", nil)
+						if err != nil {
+							return nil, err
+						}
+						_, err = result.Consume()
+						return nil, err
+					}
+
+					_, err := sess.WriteTransaction(work)
+					AssertNoError(t, err)
+					AssertIntEqual(t, numRetries, 2)
+					AssertIntEqual(t, len(manager.expired), 1)
+					expected := []event.Event{event.TokenExpired{Code: tokenExpiredErr.Code, Message: tokenExpiredErr.Msg}}
+					if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+						t.Errorf("Expected events %v but was %v", expected, events)
+					}
+
+					// Without a manager the error is returned as is
+					sess.auth = nil
+					fail(conn, tokenExpiredErr)
+					numRetries = 0
+					_, err = sess.WriteTransaction(work)
+					assertTokenExpiredError(t, err)
+					AssertIntEqual(t, numRetries, 1)
+				})
+			}
+		})
+
 		rt.Run("Retrieves default database name for impersonated user", func(t *testing.T) {
 			sessConfig := SessionConfig{ImpersonatedUser: "me"}
 			router, pool, sess := createSessionFromConfig(sessConfig)
@@ -223,6 +298,45 @@ func TestSession(st *testing.T) {
 		})
 	})
 
+	st.Run("Default transaction config", func(t *testing.T) {
+		conf := Config{
+			MaxTransactionRetryTime: 3 * time.Millisecond,
+			DefaultTxMetadata:       map[string]interface{}{"app": "shop", "tenant": "driver"},
+			DefaultTxTimeout:        time.Minute,
+		}
+		sessConfig := SessionConfig{
+			DefaultTxMetadata: map[string]interface{}{"tenant": "acme", "request": 1},
+			DefaultTxTimeout:  time.Second,
+		}
+		pool := PoolFake{}
+		sess := newSession(&conf, sessConfig, &RouterFake{}, &pool, &logger, &event.Void{}, nil)
+		conn := &ConnFake{Alive: true}
+		pool.BorrowConn = conn
+
+		_, err := sess.Run("cypher", nil, WithTxMetadata(map[string]interface{}{"request": 2}))
+		AssertNoError(t, err)
+		tx, err := sess.BeginTransaction(WithTxTimeout(time.Hour))
+		AssertNoError(t, err)
+		AssertNoError(t, tx.Commit())
+		_, err = sess.WriteTransaction(func(tx Transaction) (interface{}, error) { return nil, nil })
+		AssertNoError(t, err)
+		// Explicitly uses the timeout of the server
+		_, err = sess.Run("cypher", nil, WithTxTimeout(0))
+		AssertNoError(t, err)
+
+		expected := []RecordedTx{
+			{Origin: "Run", Timeout: time.Second, Meta: map[string]interface{}{"app": "shop", "tenant": "acme", "request": 2}},
+			{Origin: "TxBegin", Timeout: time.Hour, Meta: map[string]interface{}{"app": "shop", "tenant": "acme", "request": 1}},
+			{Origin: "TxBegin", Mode: db.WriteMode, Timeout: time.Second, Meta: map[string]interface{}{"app": "shop", "tenant": "acme", "request": 1}},
+			{Origin: "Run", Timeout: 0, Meta: map[string]interface{}{"app": "shop", "tenant": "acme", "request": 1}},
+		}
+		if !reflect.DeepEqual(conn.RecordedTxs, expected) {
+			t.Errorf("Expected transactions %+v but were %+v", expected, conn.RecordedTxs)
+		}
+		// The defaults are not modified by merging
+		AssertIntEqual(t, len(sessConfig.DefaultTxMetadata), 2)
+	})
+
 	st.Run("Run", func(bt *testing.T) {
 		bt.Run("Forces reset on acquired connection", func(t *testing.T) {
 			_, pool, sess := createSession()
@@ -259,6 +373,14 @@ func TestSession(st *testing.T) {
 			AssertIntEqual(t, forceResetCalls, 2)
 		})
 
+		bt.Run("Fails on unsupported parameter before borrowing connection", func(t *testing.T) {
+			_, pool, sess := createSession()
+			pool.BorrowErr = errors.New("should not borrow")
+
+			_, err := sess.Run("cypher", map[string]interface{}{"x": make(chan int)})
+			assertUsageError(t, err)
+		})
+
 		// Checks that chained Run results are buffered and that bookmarks are retrieved for
 		// those and that a Consume on the last result also gives the appropriate bookmark.
 		bt.Run("Chained and consume", func(t *testing.T) {
@@ -476,9 +598,68 @@ func TestSession(st *testing.T) {
 
 			assertTokenExpiredError(t, err)
 		})
+
+		bt.Run("Token expiration notifies listener", func(t *testing.T) {
+			_, pool, sess := createSession()
+			listener := &ListenerFake{}
+			sess.listener = listener
+			pool.BorrowErr = tokenExpiredErr
+
+			_, err := sess.Run("cypher", map[string]interface{}{})
+
+			assertTokenExpiredError(t, err)
+			expected := []event.Event{event.TokenExpired{Code: tokenExpiredErr.Code, Message: tokenExpiredErr.Msg}}
+			if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+				t.Errorf("Expected events %v but was %v", expected, events)
+			}
+		})
+
+		bt.Run("Database not found invalidates database", func(t *testing.T) {
+			router, pool, sess := createSession()
+			router.GetNameOfDefaultDbHook = func(user string) (string, error) { return "home", nil }
+			pool.BorrowErr = &db.Neo4jError{Code: "Neo.ClientError.Database.DatabaseNotFound"}
+
+			_, err := sess.Run("cypher", map[string]interface{}{})
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !router.Invalidated || router.InvalidatedDb != "home" {
+				t.Errorf("Expected database to be invalidated")
+			}
+		})
+
+		bt.Run("Failures are reported to the pool", func(t *testing.T) {
+			_, pool, sess := createSession()
+			unavailable := &db.Neo4jError{Code: "Neo.TransientError.General.DatabaseUnavailable"}
+			pool.BorrowConn = &ConnFake{Alive: true, RunErr: unavailable}
+
+			_, err := sess.Run("cypher", map[string]interface{}{})
+
+			AssertError(t, err)
+			AssertIntEqual(t, len(pool.FailedErrs), 1)
+			AssertTrue(t, pool.FailedErrs[0] == error(unavailable))
+		})
 	})
 
 	st.Run("Explicit transaction", func(bt *testing.T) {
+		bt.Run("Database not found invalidates database", func(t *testing.T) {
+			router, pool, sess := createSession()
+			router.GetNameOfDefaultDbHook = func(user string) (string, error) { return "home", nil }
+			pool.BorrowConn = &ConnFake{Alive: true, RunTxErr: &db.Neo4jError{Code: "Neo.ClientError.Database.DatabaseNotFound"}}
+
+			tx, err := sess.BeginTransaction()
+			AssertNoError(t, err)
+			_, err = tx.Run("cypher", nil)
+
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !router.Invalidated || router.InvalidatedDb != "home" {
+				t.Errorf("Expected database to be invalidated")
+			}
+		})
+
 		bt.Run("While already in tx", func(t *testing.T) {
 			_, pool, sess := createSession()
 			conn := &ConnFake{Alive: true}
@@ -594,6 +775,51 @@ func TestSession(st *testing.T) {
 
 			assertTokenExpiredError(t, err)
 		})
+
+		bt.Run("Token expiration in transaction notifies listener", func(t *testing.T) {
+			cases := map[string]struct {
+				conn *ConnFake
+				use  func(tx Transaction) error
+			}{
+				"run": {
+					conn: &ConnFake{Alive: true, RunTxErr: tokenExpiredErr},
+					use: func(tx Transaction) error {
+						_, err := tx.Run("cypher", nil)
+						return err
+					},
+				},
+				"commit": {
+					conn: &ConnFake{Alive: true, TxCommitErr: tokenExpiredErr},
+					use:  func(tx Transaction) error { return tx.Commit() },
+				},
+				"result": {
+					conn: &ConnFake{Alive: true, Nexts: []Next{{Err: tokenExpiredErr}}},
+					use: func(tx Transaction) error {
+						res, err := tx.Run("cypher", nil)
+						AssertNoError(t, err)
+						res.Next()
+						res.Next()
+						return res.Err()
+					},
+				},
+			}
+			for name, c := range cases {
+				_, pool, sess := createSession()
+				listener := &ListenerFake{}
+				sess.listener = listener
+				pool.BorrowConn = c.conn
+
+				tx, err := sess.BeginTransaction()
+				AssertNoError(t, err)
+				err = c.use(tx)
+
+				assertTokenExpiredError(t, err)
+				expected := []event.Event{event.TokenExpired{Code: tokenExpiredErr.Code, Message: tokenExpiredErr.Msg}}
+				if events := listener.Events(); !reflect.DeepEqual(events, expected) {
+					t.Errorf("%s: expected events %v but was %v", name, expected, events)
+				}
+			}
+		})
 	})
 
 	st.Run("Close", func(ct *testing.T) {
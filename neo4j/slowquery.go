@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+)
+
+// SlowQuery describes a query that exceeded Config.SlowQueryThreshold.
+type SlowQuery struct {
+	Cypher string
+	// Params describes the type of each parameter without revealing the values, like
+	// "string", "int64", "list(3)" or "map(2)".
+	Params   map[string]string
+	Database string
+	// Server is the address of the server that executed the query.
+	Server string
+	// Attempt is the attempt number of the transaction function the query belongs to, always
+	// 1 for queries that aren't executed in a transaction function.
+	Attempt int
+	// RetryTime is the time spent on previous attempts of the transaction function the query
+	// belongs to, including the waits between them. Zero for the first attempt and for
+	// queries that aren't executed in a transaction function.
+	RetryTime time.Duration
+	// ResultAvailableAfter and ResultConsumedAfter are the timings reported by the server,
+	// zero when the query failed.
+	ResultAvailableAfter time.Duration
+	ResultConsumedAfter  time.Duration
+	// WallTime is the time measured by the driver from the call to Run until the result was
+	// consumed or the query failed. For auto-commit queries it includes waiting for a
+	// connection.
+	WallTime time.Duration
+	// Err is the error that the query failed with, nil when it succeeded.
+	Err error
+}
+
+func (q SlowQuery) String() string {
+	keys := make([]string, 0, len(q.Params))
+	for k := range q.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = k + ": " + q.Params[k]
+	}
+	s := fmt.Sprintf("Slow query on %s, database %s, attempt %d, retry time %s, wall time %s, available after %s, consumed after %s: %s {%s}",
+		q.Server, q.Database, q.Attempt, q.RetryTime, q.WallTime, q.ResultAvailableAfter, q.ResultConsumedAfter,
+		q.Cypher, strings.Join(params, ", "))
+	if q.Err != nil {
+		s += fmt.Sprintf(" failed: %s", q.Err)
+	}
+	return s
+}
+
+// SlowQueryLogger receives queries that exceeded Config.SlowQueryThreshold.
+type SlowQueryLogger interface {
+	LogSlowQuery(query SlowQuery)
+}
+
+// Used when no SlowQueryLogger has been configured.
+type slowQueryWarner struct {
+	log   log.Logger
+	logId string
+}
+
+func (w *slowQueryWarner) LogSlowQuery(query SlowQuery) {
+	w.log.Warnf(log.Session, w.logId, "%s", query)
+}
+
+// Measures queries and reports those that exceed the threshold.
+type slowQueryLog struct {
+	threshold time.Duration
+	logger    SlowQueryLogger
+	now       func() time.Time
+	attempt   int
+	retryTime time.Duration
+	// Reported for failed queries, the summary tells the database otherwise
+	database string
+	// Set by startQuery
+	start time.Time
+}
+
+// Returns a copy that measures a query that is run now.
+func (l *slowQueryLog) startQuery() *slowQueryLog {
+	if l == nil {
+		return nil
+	}
+	return l.startQueryAt(l.now())
+}
+
+// Returns a copy that measures a query that was started at start, like before waiting for
+// a connection.
+func (l *slowQueryLog) startQueryAt(start time.Time) *slowQueryLog {
+	if l == nil {
+		return nil
+	}
+	q := *l
+	q.start = start
+	return &q
+}
+
+// Reports the query if it exceeded the threshold, summary is nil when the query failed with err.
+func (l *slowQueryLog) check(cypher string, params map[string]interface{}, server string, summary *db.Summary, err error) {
+	if l == nil {
+		return
+	}
+	query := SlowQuery{
+		Cypher:    cypher,
+		Database:  l.database,
+		Server:    server,
+		Attempt:   l.attempt,
+		RetryTime: l.retryTime,
+		WallTime:  l.now().Sub(l.start),
+		Err:       err,
+	}
+	if summary != nil {
+		query.Database = summary.Database
+		query.ResultAvailableAfter = time.Duration(summary.TFirst) * time.Millisecond
+		query.ResultConsumedAfter = time.Duration(summary.TLast) * time.Millisecond
+	}
+	if query.WallTime <= l.threshold && query.ResultAvailableAfter+query.ResultConsumedAfter <= l.threshold {
+		return
+	}
+	query.Params = redactParams(params)
+	l.logger.LogSlowQuery(query)
+}
+
+func redactParams(params map[string]interface{}) map[string]string {
+	redacted := make(map[string]string, len(params))
+	for k, v := range params {
+		redacted[k] = describeParam(v)
+	}
+	return redacted
+}
+
+func describeParam(x interface{}) string {
+	if x == nil {
+		return "null"
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("bytes(%d)", v.Len())
+		}
+		return fmt.Sprintf("list(%d)", v.Len())
+	case reflect.Map:
+		return fmt.Sprintf("map(%d)", v.Len())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "null"
+		}
+		return describeParam(v.Elem().Interface())
+	}
+	return v.Type().String()
+}
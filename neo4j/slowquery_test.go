@@ -0,0 +1,228 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package neo4j
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/db"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/event"
+	. "github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/testutil"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/log"
+)
+
+type slowQueryLoggerFake struct {
+	queries []SlowQuery
+}
+
+func (l *slowQueryLoggerFake) LogSlowQuery(query SlowQuery) {
+	l.queries = append(l.queries, query)
+}
+
+func TestSlowQueryLog(outer *testing.T) {
+	start := time.Unix(1000, 0)
+	params := map[string]interface{}{
+		"name": "secret", "ids": []int{1, 2}, "meta": map[string]interface{}{"a": 1}, "none": nil, "data": []byte{1}}
+	summary := &db.Summary{TFirst: 40, TLast: 30, Database: "movies", ServerName: "server:7687"}
+
+	check := func(wallTime time.Duration, summary *db.Summary, err error) []SlowQuery {
+		logger := &slowQueryLoggerFake{}
+		now := start
+		l := &slowQueryLog{
+			threshold: 100 * time.Millisecond,
+			logger:    logger,
+			now:       func() time.Time { return now },
+			attempt:   2,
+			retryTime: time.Second,
+			database:  "session db",
+		}
+		q := l.startQuery()
+		now = now.Add(wallTime)
+		q.check("MATCH (n) RETURN n", params, "server:7687", summary, err)
+		return logger.queries
+	}
+
+	outer.Run("Below threshold", func(t *testing.T) {
+		AssertIntEqual(t, len(check(100*time.Millisecond, summary, nil)), 0)
+	})
+
+	outer.Run("Server time exceeded", func(t *testing.T) {
+		queries := check(time.Millisecond, &db.Summary{TFirst: 60, TLast: 50}, nil)
+		AssertIntEqual(t, len(queries), 1)
+	})
+
+	outer.Run("Wall time exceeded", func(t *testing.T) {
+		queries := check(150*time.Millisecond, summary, nil)
+		AssertIntEqual(t, len(queries), 1)
+		expected := SlowQuery{
+			Cypher:               "MATCH (n) RETURN n",
+			Params:               map[string]string{"name": "string", "ids": "list(2)", "meta": "map(1)", "none": "null", "data": "bytes(1)"},
+			Database:             "movies",
+			Server:               "server:7687",
+			Attempt:              2,
+			RetryTime:            time.Second,
+			ResultAvailableAfter: 40 * time.Millisecond,
+			ResultConsumedAfter:  30 * time.Millisecond,
+			WallTime:             150 * time.Millisecond,
+		}
+		if !reflect.DeepEqual(queries[0], expected) {
+			t.Errorf("Expected %+v but was %+v", expected, queries[0])
+		}
+		AssertStringEqual(t, queries[0].String(), "Slow query on server:7687, database movies, attempt 2, "+
+			"retry time 1s, wall time 150ms, available after 40ms, consumed after 30ms: MATCH (n) RETURN n "+
+			"{data: bytes(1), ids: list(2), meta: map(1), name: string, none: null}")
+	})
+
+	outer.Run("Failed", func(t *testing.T) {
+		err := &db.Neo4jError{Code: "Neo.ClientError.Transaction.TransactionTimedOut", Msg: "timed out"}
+		AssertIntEqual(t, len(check(50*time.Millisecond, nil, err)), 0)
+		queries := check(150*time.Millisecond, nil, err)
+		AssertIntEqual(t, len(queries), 1)
+		AssertStringEqual(t, queries[0].Database, "session db")
+		AssertTrue(t, queries[0].Err == error(err))
+		AssertTrue(t, strings.HasSuffix(queries[0].String(), " failed: "+err.Error()))
+	})
+}
+
+func TestSlowQueryLogInTransactionFunction(t *testing.T) {
+	logger := &slowQueryLoggerFake{}
+	conf := Config{MaxTransactionRetryTime: time.Hour, SlowQueryThreshold: 500 * time.Millisecond, SlowQueryLogger: logger}
+	pool := &PoolFake{}
+	sess := newSession(&conf, SessionConfig{}, &RouterFake{}, pool, log.Void{}, &event.Void{}, nil)
+	now := time.Unix(1000, 0)
+	sess.now = func() time.Time { return now }
+	sess.sleep = func(d time.Duration) { now = now.Add(d) }
+	sess.throttleTime = time.Second
+	pool.BorrowConn = &ConnFake{Alive: true, ConsumeSum: &db.Summary{Database: "movies"}}
+
+	attempts := 0
+	_, err := sess.WriteTransaction(func(tx Transaction) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, &db.Neo4jError{Code: "Neo.TransientError.General.Whatever"}
+		}
+		slow, err := tx.Run("CREATE ()", nil)
+		AssertNoError(t, err)
+		now = now.Add(600 * time.Millisecond)
+		_, err = slow.Consume()
+		AssertNoError(t, err)
+		// Measured from its own run, not from the start of the transaction function
+		fast, err := tx.Run("RETURN 1", nil)
+		AssertNoError(t, err)
+		_, err = fast.Consume()
+		return nil, err
+	})
+	AssertNoError(t, err)
+	AssertIntEqual(t, len(logger.queries), 1)
+	AssertStringEqual(t, logger.queries[0].Cypher, "CREATE ()")
+	AssertIntEqual(t, logger.queries[0].Attempt, 2)
+	AssertStringEqual(t, logger.queries[0].Database, "movies")
+	AssertTrue(t, logger.queries[0].WallTime == 600*time.Millisecond)
+	// The sleep between the attempts is at least 800ms due to jitter
+	AssertTrue(t, logger.queries[0].RetryTime >= 800*time.Millisecond)
+}
+
+func TestSlowQueryLogInExplicitTransaction(t *testing.T) {
+	logger := &slowQueryLoggerFake{}
+	conf := Config{SlowQueryThreshold: 500 * time.Millisecond, SlowQueryLogger: logger}
+	pool := &PoolFake{}
+	sess := newSession(&conf, SessionConfig{}, &RouterFake{}, pool, log.Void{}, &event.Void{}, nil)
+	now := time.Unix(1000, 0)
+	sess.now = func() time.Time { return now }
+	conn := &ConnFake{Alive: true, Name: "server:7687", ConsumeSum: &db.Summary{Database: "movies"}}
+	// Every run takes a while before the server responds
+	conn.RunTxHook = func() { now = now.Add(600 * time.Millisecond) }
+	pool.BorrowConn = conn
+
+	tx, err := sess.BeginTransaction()
+	AssertNoError(t, err)
+	_, err = tx.Run("CREATE ()", nil)
+	AssertNoError(t, err)
+	timeout := &db.Neo4jError{Code: "Neo.ClientError.Transaction.TransactionTimedOut", Msg: "timed out"}
+	conn.RunTxErr = timeout
+	_, err = tx.Run("MATCH (n) RETURN n", nil)
+	AssertError(t, err)
+	conn.RunTxErr = nil
+	AssertNoError(t, tx.Commit())
+
+	AssertIntEqual(t, len(logger.queries), 2)
+	failed, notConsumed := logger.queries[0], logger.queries[1]
+	AssertStringEqual(t, failed.Cypher, "MATCH (n) RETURN n")
+	AssertStringEqual(t, failed.Server, "server:7687")
+	AssertTrue(t, failed.Err == error(timeout))
+	AssertTrue(t, failed.WallTime == 600*time.Millisecond)
+	// Result that wasn't consumed is measured until the transaction discards it
+	AssertStringEqual(t, notConsumed.Cypher, "CREATE ()")
+	AssertNil(t, notConsumed.Err)
+	AssertTrue(t, notConsumed.WallTime == 1200*time.Millisecond)
+}
+
+func TestSlowQueryLogInAutoCommit(outer *testing.T) {
+	setup := func(sessConfig SessionConfig) (*slowQueryLoggerFake, *session, *PoolFake, *RouterFake, *time.Time) {
+		logger := &slowQueryLoggerFake{}
+		conf := Config{SlowQueryThreshold: 500 * time.Millisecond, SlowQueryLogger: logger}
+		pool := &PoolFake{}
+		router := &RouterFake{}
+		sess := newSession(&conf, sessConfig, router, pool, log.Void{}, &event.Void{}, nil)
+		now := time.Unix(1000, 0)
+		sess.now = func() time.Time { return now }
+		return logger, sess, pool, router, &now
+	}
+
+	outer.Run("Reports resolved home database of failed query", func(t *testing.T) {
+		logger, sess, pool, router, now := setup(SessionConfig{ImpersonatedUser: "me"})
+		// Waiting for the router is included in the wall time
+		router.GetNameOfDefaultDbHook = func(user string) (string, error) {
+			*now = now.Add(600 * time.Millisecond)
+			return "mydb", nil
+		}
+		timeout := &db.Neo4jError{Code: "Neo.ClientError.Transaction.TransactionTimedOut", Msg: "timed out"}
+		pool.BorrowConn = &ConnFake{Alive: true, Name: "server:7687", RunErr: timeout}
+
+		_, err := sess.Run("MATCH (n) RETURN n", nil)
+		AssertError(t, err)
+		AssertIntEqual(t, len(logger.queries), 1)
+		AssertStringEqual(t, logger.queries[0].Database, "mydb")
+		AssertTrue(t, logger.queries[0].Err == error(timeout))
+		AssertTrue(t, logger.queries[0].WallTime == 600*time.Millisecond)
+	})
+
+	outer.Run("Reports failure to get a connection", func(t *testing.T) {
+		logger, sess, _, router, now := setup(SessionConfig{DatabaseName: "movies"})
+		borrowErr := errors.New("timed out waiting for connection")
+		router.ReadersHook = func(bookmarks []string, database string) ([]string, error) {
+			*now = now.Add(600 * time.Millisecond)
+			return nil, borrowErr
+		}
+		router.WritersHook = router.ReadersHook
+
+		_, err := sess.Run("MATCH (n) RETURN n", nil)
+		AssertError(t, err)
+		AssertIntEqual(t, len(logger.queries), 1)
+		AssertStringEqual(t, logger.queries[0].Database, "movies")
+		AssertStringEqual(t, logger.queries[0].Server, "")
+		AssertTrue(t, logger.queries[0].Err == borrowErr)
+		AssertTrue(t, logger.queries[0].WallTime == 600*time.Millisecond)
+	})
+}
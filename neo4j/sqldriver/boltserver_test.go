@@ -0,0 +1,312 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqldriver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/internal/packstream"
+)
+
+const (
+	msgHello    byte = 0x01
+	msgGoodbye  byte = 0x02
+	msgReset    byte = 0x0f
+	msgRun      byte = 0x10
+	msgBegin    byte = 0x11
+	msgCommit   byte = 0x12
+	msgRollback byte = 0x13
+	msgDiscard  byte = 0x2f
+	msgPull     byte = 0x3f
+	msgSuccess  byte = 0x70
+	msgRecord   byte = 0x71
+	msgIgnored  byte = 0x7e
+	msgFailure  byte = 0x7f
+)
+
+// Packstream structure sent by the stub, like nodes and dates.
+type boltStruct struct {
+	tag    byte
+	fields []interface{}
+}
+
+// Response of the stub server to a query.
+type boltResponse struct {
+	fields  []interface{}
+	records [][]interface{}
+	stats   map[string]interface{}
+	// Code of the failure to respond with instead of running the query
+	failure string
+}
+
+type boltMessage struct {
+	tag    byte
+	fields []interface{}
+}
+
+// Stub of a Bolt 4.4 server that responds to queries with scripted responses and keeps track
+// of the received messages. Queries without response return no records.
+type boltServer struct {
+	t         *testing.T
+	listener  net.Listener
+	responses map[string]boltResponse
+	mut       sync.Mutex
+	messages  []boltMessage
+}
+
+func startBoltServer(t *testing.T, responses map[string]boltResponse) *boltServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &boltServer{t: t, listener: listener, responses: responses}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *boltServer) address() string {
+	return s.listener.Addr().String()
+}
+
+// Returns the tags of the received messages, except hello, reset and goodbye.
+func (s *boltServer) received() []byte {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	var tags []byte
+	for _, m := range s.messages {
+		if m.tag != msgHello && m.tag != msgReset && m.tag != msgGoodbye {
+			tags = append(tags, m.tag)
+		}
+	}
+	return tags
+}
+
+// Returns the fields of the last received run message.
+func (s *boltServer) lastRun() []interface{} {
+	return s.last(msgRun)
+}
+
+// Returns the fields of the last received begin message.
+func (s *boltServer) lastBegin() []interface{} {
+	return s.last(msgBegin)
+}
+
+func (s *boltServer) last(tag byte) []interface{} {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].tag == tag {
+			return s.messages[i].fields
+		}
+	}
+	return nil
+}
+
+func (s *boltServer) serve(conn net.Conn) {
+	defer conn.Close()
+	handshake := make([]byte, 20)
+	if _, err := io.ReadFull(conn, handshake); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{0, 0, 4, 4}); err != nil {
+		return
+	}
+
+	failed := false
+	var pending *boltResponse
+	for {
+		msg, err := receive(conn)
+		if err != nil {
+			return
+		}
+		s.mut.Lock()
+		s.messages = append(s.messages, msg)
+		s.mut.Unlock()
+
+		if failed && msg.tag != msgReset && msg.tag != msgGoodbye {
+			send(conn, msgIgnored)
+			continue
+		}
+		switch msg.tag {
+		case msgHello:
+			send(conn, msgSuccess, map[string]interface{}{"server": "Neo4j/4.4.0", "connection_id": "bolt-1"})
+		case msgGoodbye:
+			return
+		case msgReset:
+			failed = false
+			send(conn, msgSuccess, map[string]interface{}{})
+		case msgBegin, msgRollback:
+			send(conn, msgSuccess, map[string]interface{}{})
+		case msgCommit:
+			send(conn, msgSuccess, map[string]interface{}{"bookmark": "bm"})
+		case msgRun:
+			response := s.responses[msg.fields[0].(string)]
+			if response.failure != "" {
+				failed = true
+				send(conn, msgFailure, map[string]interface{}{"code": response.failure, "message": "failed"})
+				continue
+			}
+			pending = &response
+			send(conn, msgSuccess, map[string]interface{}{"fields": response.fields, "t_first": int64(0)})
+		case msgPull, msgDiscard:
+			if pending != nil && msg.tag == msgPull {
+				for _, record := range pending.records {
+					send(conn, msgRecord, record)
+				}
+			}
+			meta := map[string]interface{}{"has_more": false, "type": "rw", "t_last": int64(0), "db": "neo4j"}
+			if pending != nil && pending.stats != nil {
+				meta["stats"] = pending.stats
+			}
+			pending = nil
+			send(conn, msgSuccess, meta)
+		default:
+			s.t.Errorf("Unexpected message %#x", msg.tag)
+			return
+		}
+	}
+}
+
+func receive(conn net.Conn) (boltMessage, error) {
+	var buf []byte
+	for {
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return boltMessage{}, err
+		}
+		n := binary.BigEndian.Uint16(size[:])
+		if n == 0 {
+			break
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(conn, chunk); err != nil {
+			return boltMessage{}, err
+		}
+		buf = append(buf, chunk...)
+	}
+	unpacker := &packstream.Unpacker{}
+	unpacker.Reset(buf)
+	unpacker.Next()
+	n := unpacker.Len()
+	msg := boltMessage{tag: unpacker.StructTag(), fields: make([]interface{}, n)}
+	for i := range msg.fields {
+		unpacker.Next()
+		msg.fields[i] = unpack(unpacker)
+	}
+	return msg, unpacker.Err
+}
+
+func unpack(unpacker *packstream.Unpacker) interface{} {
+	switch unpacker.Curr {
+	case packstream.PackedInt:
+		return unpacker.Int()
+	case packstream.PackedFloat:
+		return unpacker.Float()
+	case packstream.PackedStr:
+		return unpacker.String()
+	case packstream.PackedByteArray:
+		return unpacker.ByteArray()
+	case packstream.PackedArray:
+		a := make([]interface{}, unpacker.Len())
+		for i := range a {
+			unpacker.Next()
+			a[i] = unpack(unpacker)
+		}
+		return a
+	case packstream.PackedMap:
+		n := unpacker.Len()
+		m := make(map[string]interface{}, n)
+		for ; n > 0; n-- {
+			unpacker.Next()
+			key := unpacker.String()
+			unpacker.Next()
+			m[key] = unpack(unpacker)
+		}
+		return m
+	case packstream.PackedTrue:
+		return true
+	case packstream.PackedFalse:
+		return false
+	}
+	return nil
+}
+
+func send(conn net.Conn, tag byte, fields ...interface{}) {
+	packer := &packstream.Packer{}
+	packer.Begin(nil)
+	pack(packer, boltStruct{tag: tag, fields: fields})
+	buf, err := packer.End()
+	if err != nil {
+		panic(err)
+	}
+	chunk := make([]byte, 2, len(buf)+4)
+	binary.BigEndian.PutUint16(chunk, uint16(len(buf)))
+	chunk = append(append(chunk, buf...), 0, 0)
+	conn.Write(chunk)
+}
+
+func pack(packer *packstream.Packer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		packer.Nil()
+	case bool:
+		packer.Bool(v)
+	case int64:
+		packer.Int64(v)
+	case float64:
+		packer.Float64(v)
+	case string:
+		packer.String(v)
+	case []byte:
+		packer.Bytes(v)
+	case []interface{}:
+		packer.ArrayHeader(len(v))
+		for _, x := range v {
+			pack(packer, x)
+		}
+	case map[string]interface{}:
+		packer.MapHeader(len(v))
+		for k, x := range v {
+			packer.String(k)
+			pack(packer, x)
+		}
+	case boltStruct:
+		packer.StructHeader(v.tag, len(v.fields))
+		for _, x := range v.fields {
+			pack(packer, x)
+		}
+	default:
+		panic(fmt.Sprintf("Can not pack %T", value))
+	}
+}
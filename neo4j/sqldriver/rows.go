@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+)
+
+type rows struct {
+	result neo4j.Result
+	keys   []string
+	// First record, fetched up front to know the column types before Next is called
+	first   *neo4j.Record
+	current *neo4j.Record
+}
+
+func newRows(result neo4j.Result) (*rows, error) {
+	keys, err := result.Keys()
+	if err != nil {
+		return nil, err
+	}
+	r := &rows{result: result, keys: keys}
+	if result.Next() {
+		r.first = result.Record()
+	} else if err = result.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rows) Columns() []string {
+	return r.keys
+}
+
+// Close discards the remaining records.
+func (r *rows) Close() error {
+	_, err := r.result.Consume()
+	return err
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.first != nil {
+		r.current, r.first = r.first, nil
+	} else if r.result.Next() {
+		r.current = r.result.Record()
+	} else {
+		r.current = nil
+		if err := r.result.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	for i, value := range r.current.Values {
+		dest[i] = sqlValue(value)
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName returns the Cypher type of the value in the current row, or in
+// the first row before Next has been called. Returns an empty string when there are no rows.
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	record := r.current
+	if record == nil {
+		record = r.first
+	}
+	if record == nil {
+		return ""
+	}
+	return typeName(record.Values[index])
+}
+
+// Converts temporal values to time.Time to make them scannable by database/sql.
+func sqlValue(value interface{}) driver.Value {
+	switch v := value.(type) {
+	case dbtype.Date:
+		return v.Time()
+	case dbtype.LocalDateTime:
+		return v.Time()
+	case dbtype.LocalTime:
+		return v.Time()
+	case dbtype.Time:
+		return v.Time()
+	}
+	return value
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return "BOOLEAN"
+	case int64:
+		return "INTEGER"
+	case float64:
+		return "FLOAT"
+	case string:
+		return "STRING"
+	case []byte:
+		return "BYTES"
+	case []interface{}:
+		return "LIST"
+	case map[string]interface{}:
+		return "MAP"
+	case dbtype.Node:
+		return "NODE"
+	case dbtype.Relationship:
+		return "RELATIONSHIP"
+	case dbtype.Path:
+		return "PATH"
+	case dbtype.Point2D, dbtype.Point3D:
+		return "POINT"
+	case dbtype.Date:
+		return "DATE"
+	case dbtype.Time:
+		return "ZONED TIME"
+	case dbtype.LocalTime:
+		return "LOCAL TIME"
+	case time.Time:
+		return "ZONED DATETIME"
+	case dbtype.LocalDateTime:
+		return "LOCAL DATETIME"
+	case dbtype.Duration:
+		return "DURATION"
+	}
+	return ""
+}
+
+type result struct {
+	rowsAffected int64
+}
+
+// Rows affected is the number of created and deleted nodes and relationships and the
+// number of set properties.
+func newResult(summary neo4j.ResultSummary) *result {
+	counters := summary.Counters()
+	affected := counters.NodesCreated() + counters.NodesDeleted() +
+		counters.RelationshipsCreated() + counters.RelationshipsDeleted() +
+		counters.PropertiesSet()
+	return &result{rowsAffected: int64(affected)}
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by Neo4j")
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
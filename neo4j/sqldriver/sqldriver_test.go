@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) "Neo4j"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/dbtype"
+)
+
+func openDB(t *testing.T, server *boltServer) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("neo4j", "bolt://user:secret@"+server.address()+"?database=movies&max_connection_pool_size=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func assertReceived(t *testing.T, server *boltServer, expected ...byte) {
+	t.Helper()
+	if received := server.received(); !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expected messages %#v but was %#v", expected, received)
+	}
+}
+
+func TestQuery(ot *testing.T) {
+	ot.Run("Binds parameters and scans rows", func(t *testing.T) {
+		server := startBoltServer(t, map[string]boltResponse{
+			"MATCH (p:Person {name: $name}) RETURN p.name AS name, p.born AS born, $1 AS arg": {
+				fields: []interface{}{"name", "born", "arg"},
+				records: [][]interface{}{
+					{"Keanu", int64(1964), "x"},
+					{"Carrie", int64(1967), "x"},
+				},
+			},
+		})
+		db := openDB(t, server)
+
+		rows, err := db.Query("MATCH (p:Person {name: $name}) RETURN p.name AS name, p.born AS born, $1 AS arg",
+			"x", sql.Named("name", "Keanu"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		columns, _ := rows.Columns()
+		if !reflect.DeepEqual(columns, []string{"name", "born", "arg"}) {
+			t.Errorf("Unexpected columns %v", columns)
+		}
+		var names []string
+		for rows.Next() {
+			var name, arg string
+			var born int
+			if err = rows.Scan(&name, &born, &arg); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		if err = rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, []string{"Keanu", "Carrie"}) {
+			t.Errorf("Unexpected names %v", names)
+		}
+
+		run := server.lastRun()
+		if params := run[1]; !reflect.DeepEqual(params, map[string]interface{}{"name": "Keanu", "1": "x"}) {
+			t.Errorf("Unexpected parameters %v", params)
+		}
+		if db := run[2].(map[string]interface{})["db"]; db != "movies" {
+			t.Errorf("Expected query to run in database movies but was %v", db)
+		}
+	})
+
+	ot.Run("Column database types", func(t *testing.T) {
+		node := boltStruct{tag: 'N', fields: []interface{}{int64(1), []interface{}{"Person"}, map[string]interface{}{}}}
+		date := boltStruct{tag: 'D', fields: []interface{}{int64(365)}}
+		server := startBoltServer(t, map[string]boltResponse{
+			"RETURN values": {
+				fields:  []interface{}{"node", "date", "int", "list", "null"},
+				records: [][]interface{}{{node, date, int64(1), []interface{}{}, nil}},
+			},
+		})
+		db := openDB(t, server)
+
+		rows, err := db.Query("RETURN values")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, columnType := range types {
+			names = append(names, columnType.DatabaseTypeName())
+		}
+		if !reflect.DeepEqual(names, []string{"NODE", "DATE", "INTEGER", "LIST", "NULL"}) {
+			t.Errorf("Unexpected type names %v", names)
+		}
+
+		rows.Next()
+		var scannedNode interface{}
+		var scannedDate time.Time
+		var i int
+		var list, null interface{}
+		if err = rows.Scan(&scannedNode, &scannedDate, &i, &list, &null); err != nil {
+			t.Fatal(err)
+		}
+		if n, isNode := scannedNode.(dbtype.Node); !isNode || n.Labels[0] != "Person" {
+			t.Errorf("Expected node but was %v", scannedNode)
+		}
+		if !scannedDate.Equal(time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected date %s", scannedDate)
+		}
+	})
+
+	ot.Run("Failure", func(t *testing.T) {
+		server := startBoltServer(t, map[string]boltResponse{
+			"RETURN": {failure: "Neo.ClientError.Statement.SyntaxError"},
+		})
+		db := openDB(t, server)
+
+		_, err := db.Query("RETURN")
+		if err == nil || !strings.Contains(err.Error(), "SyntaxError") {
+			t.Errorf("Expected syntax error but was %v", err)
+		}
+	})
+}
+
+func TestExec(t *testing.T) {
+	server := startBoltServer(t, map[string]boltResponse{
+		"CREATE (:Person {name: $name, born: $born})": {
+			stats: map[string]interface{}{"nodes-created": int64(1), "properties-set": int64(2), "labels-added": int64(1)},
+		},
+	})
+	db := openDB(t, server)
+
+	result, err := db.Exec("CREATE (:Person {name: $name, born: $born})", sql.Named("name", "Keanu"), sql.Named("born", 1964))
+	if err != nil {
+		t.Fatal(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected != 3 {
+		t.Errorf("Expected 3 rows affected but was %d (%v)", affected, err)
+	}
+	if _, err = result.LastInsertId(); err == nil {
+		t.Errorf("Expected LastInsertId to be unsupported")
+	}
+	assertReceived(t, server, msgRun, msgPull)
+}
+
+func TestTransaction(ot *testing.T) {
+	ot.Run("Commit", func(t *testing.T) {
+		server := startBoltServer(t, nil)
+		db := openDB(t, server)
+
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tx.Exec("CREATE ()"); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		assertReceived(t, server, msgBegin, msgRun, msgPull, msgCommit)
+	})
+
+	ot.Run("Rollback", func(t *testing.T) {
+		server := startBoltServer(t, nil)
+		db := openDB(t, server)
+
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tx.Exec("CREATE ()"); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+		assertReceived(t, server, msgBegin, msgRun, msgPull, msgRollback)
+	})
+
+	// The transaction of database/sql guards against ending twice, use the driver directly
+	beginTx := func(t *testing.T) driver.Tx {
+		t.Helper()
+		server := startBoltServer(t, nil)
+		c, err := (&Driver{}).Open("bolt://" + server.address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		tx, err := c.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	ot.Run("Commit after commit", func(t *testing.T) {
+		if err := beginTx(t).Commit(); err == nil {
+			t.Errorf("Expected second commit to fail")
+		}
+	})
+
+	ot.Run("Rollback after commit", func(t *testing.T) {
+		if err := beginTx(t).Rollback(); err == nil {
+			t.Errorf("Expected rollback after commit to fail")
+		}
+	})
+
+	ot.Run("Read only", func(t *testing.T) {
+		server := startBoltServer(t, nil)
+		db := openDB(t, server)
+
+		// Bookmark of the write is passed on to the read
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tx.Exec("CREATE ()"); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		tx, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := server.lastBegin()[0].(map[string]interface{})
+		if meta["mode"] != "r" {
+			t.Errorf("Expected read transaction but mode was %v", meta["mode"])
+		}
+		if !reflect.DeepEqual(meta["bookmarks"], []interface{}{"bm"}) {
+			t.Errorf("Expected bookmark of previous write but was %v", meta["bookmarks"])
+		}
+		if _, err = tx.Query("MATCH (n) RETURN n"); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Connection is back to writing after the read-only transaction
+		tx, err = db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode, hasMode := server.lastBegin()[0].(map[string]interface{})["mode"]; hasMode {
+			t.Errorf("Expected write transaction but mode was %v", mode)
+		}
+		if err = tx.Rollback(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ot.Run("Isolation level", func(t *testing.T) {
+		server := startBoltServer(t, nil)
+		db := openDB(t, server)
+
+		_, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+		if err == nil {
+			t.Errorf("Expected isolation level to be rejected")
+		}
+	})
+}
+
+func TestConnector(t *testing.T) {
+	server := startBoltServer(t, nil)
+	driver, err := neo4j.NewDriver("bolt://"+server.address(), neo4j.NoAuth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Close()
+	db := sql.OpenDB(NewConnector(driver, neo4j.SessionConfig{DatabaseName: "reports"}))
+
+	if _, err = db.Exec("RETURN 1"); err != nil {
+		t.Fatal(err)
+	}
+	if database := server.lastRun()[2].(map[string]interface{})["db"]; database != "reports" {
+		t.Errorf("Expected query to run in database reports but was %v", database)
+	}
+	db.Close()
+	// Driver is still usable
+	if err = driver.VerifyConnectivity(); err != nil {
+		t.Errorf("Expected driver to be open but was %v", err)
+	}
+}
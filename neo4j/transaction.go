@@ -38,59 +38,121 @@ type Transaction interface {
 
 // Transaction implementation when explicit transaction started
 type transaction struct {
-	conn      db.Connection
-	fetchSize int
-	txHandle  db.TxHandle
-	done      bool
-	err       error
-	onClosed  func()
+	conn          db.Connection
+	fetchSize     int
+	prefetchRatio float64
+	txHandle      db.TxHandle
+	done          bool
+	err           error
+	onClosed      func()
+	// Handles errors received from the connection
+	onError func(error)
+	// Set when warning notifications should be logged
+	notifications *notificationLogger
+	// Set when slow queries should be logged
+	slowQueries *slowQueryLog
+	// Results that might need to be logged when the transaction ends
+	results []*result
 }
 
 func (tx *transaction) Run(cypher string, params map[string]interface{}) (Result, error) {
-	stream, err := tx.conn.RunTx(tx.txHandle, db.Command{Cypher: cypher, Params: params, FetchSize: tx.fetchSize})
+	if err := ValidateParameters(params); err != nil {
+		return nil, err
+	}
+	query := tx.slowQueries.startQuery()
+	stream, err := tx.conn.RunTx(tx.txHandle, db.Command{
+		Cypher:        cypher,
+		Params:        params,
+		FetchSize:     tx.fetchSize,
+		PrefetchRatio: tx.prefetchRatio,
+	})
 	if err != nil {
-		return nil, wrapError(err)
+		query.check(cypher, params, tx.conn.ServerName(), nil, err)
+		return nil, tx.wrapError(err)
+	}
+	res := newResult(tx.conn, stream, cypher, params)
+	res.onError = tx.onError
+	res.notifications = tx.notifications
+	res.slowQueries = query
+	if res.logging() {
+		tx.results = append(tx.results, res)
 	}
-	return newResult(tx.conn, stream, cypher, params), nil
+	return res, nil
 }
 
 func (tx *transaction) Commit() error {
 	if tx.done {
 		return tx.err
 	}
+	logPendingResults(tx.results)
 	tx.err = tx.conn.TxCommit(tx.txHandle)
 	tx.done = true
 	tx.onClosed()
-	return wrapError(tx.err)
+	return tx.wrapError(tx.err)
 }
 
 func (tx *transaction) Rollback() error {
 	if tx.done {
 		return tx.err
 	}
+	logPendingResults(tx.results)
 	tx.err = tx.conn.TxRollback(tx.txHandle)
 	tx.done = true
 	tx.onClosed()
-	return wrapError(tx.err)
+	return tx.wrapError(tx.err)
 }
 
 func (tx *transaction) Close() error {
 	return tx.Rollback()
 }
 
+// Wraps the error and handles it, see session.onError.
+func (tx *transaction) wrapError(err error) error {
+	if err != nil && tx.onError != nil {
+		tx.onError(err)
+	}
+	return wrapError(err)
+}
+
 // Transaction implementation used as parameter to transactional functions
 type retryableTransaction struct {
-	conn      db.Connection
-	fetchSize int
-	txHandle  db.TxHandle
+	conn          db.Connection
+	fetchSize     int
+	prefetchRatio float64
+	txHandle      db.TxHandle
+	// Handles errors received from the connection
+	onError func(error)
+	// Set when warning notifications should be logged
+	notifications *notificationLogger
+	// Set when slow queries should be logged
+	slowQueries *slowQueryLog
+	// Results that might need to be logged when the transaction ends
+	results []*result
 }
 
 func (tx *retryableTransaction) Run(cypher string, params map[string]interface{}) (Result, error) {
-	stream, err := tx.conn.RunTx(tx.txHandle, db.Command{Cypher: cypher, Params: params, FetchSize: tx.fetchSize})
+	if err := ValidateParameters(params); err != nil {
+		return nil, err
+	}
+	query := tx.slowQueries.startQuery()
+	stream, err := tx.conn.RunTx(tx.txHandle, db.Command{
+		Cypher:        cypher,
+		Params:        params,
+		FetchSize:     tx.fetchSize,
+		PrefetchRatio: tx.prefetchRatio,
+	})
 	if err != nil {
-		return nil, wrapError(err)
+		query.check(cypher, params, tx.conn.ServerName(), nil, err)
+		return nil, tx.wrapError(err)
+	}
+	res := newResult(tx.conn, stream, cypher, params)
+	res.onError = tx.onError
+	res.notifications = tx.notifications
+	res.slowQueries = query
+	if res.logging() {
+		tx.results = append(tx.results, res)
 	}
-	return newResult(tx.conn, stream, cypher, params), nil
+	return res, nil
 }
 
 func (tx *retryableTransaction) Commit() error {
@@ -105,6 +167,14 @@ func (tx *retryableTransaction) Close() error {
 	return &UsageError{Message: "Close not allowed on retryable transaction"}
 }
 
+// Wraps the error and handles it, see session.onError.
+func (tx *retryableTransaction) wrapError(err error) error {
+	if err != nil && tx.onError != nil {
+		tx.onError(err)
+	}
+	return wrapError(err)
+}
+
 // Represents an auto commit transaction.
 // Does not implement the Transaction interface.
 type autoTransaction struct {
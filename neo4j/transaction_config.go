@@ -19,7 +19,11 @@
 
 package neo4j
 
-import "time"
+import (
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j/retrypolicy"
+)
 
 // TransactionConfig holds the settings for explicit and auto-commit transactions. Actual configuration is expected
 // to be done using configuration functions that are predefined, i.e. 'WithTxTimeout' and 'WithTxMetadata', or one
@@ -29,6 +33,11 @@ type TransactionConfig struct {
 	Timeout time.Duration
 	// Metadata is the configured transaction metadata that will be attached to the underlying transaction.
 	Metadata map[string]interface{}
+	// RetryPolicy overrides Config.RetryPolicy for a transaction function, it is
+	// ignored for explicit and auto-commit transactions.
+	RetryPolicy retrypolicy.Policy
+	// Set by WithTxTimeout to tell a zero timeout apart from no timeout given
+	timeoutSet bool
 }
 
 // WithTxTimeout returns a transaction configuration function that applies a timeout to a transaction.
@@ -44,9 +53,13 @@ type TransactionConfig struct {
 //
 // To apply a transaction timeout to a write transaction function:
 //	session.WriteTransaction(DoWork, WithTxTimeout(5*time.Second))
+//
+// A timeout of 0 means that the timeout configured on the server is used instead of
+// SessionConfig.DefaultTxTimeout and Config.DefaultTxTimeout.
 func WithTxTimeout(timeout time.Duration) func(*TransactionConfig) {
 	return func(config *TransactionConfig) {
 		config.Timeout = timeout
+		config.timeoutSet = true
 	}
 }
 
@@ -68,3 +81,14 @@ func WithTxMetadata(metadata map[string]interface{}) func(*TransactionConfig) {
 		config.Metadata = metadata
 	}
 }
+
+// WithTxRetryPolicy returns a transaction configuration function that overrides the retry policy
+// configured on the driver for a transaction function.
+//
+// To never retry a write transaction function:
+//	session.WriteTransaction(DoWork, WithTxRetryPolicy(retrypolicy.NoRetry()))
+func WithTxRetryPolicy(policy retrypolicy.Policy) func(*TransactionConfig) {
+	return func(config *TransactionConfig) {
+		config.RetryPolicy = policy
+	}
+}
@@ -21,6 +21,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -696,9 +697,50 @@ func (b *backend) handleRequest(req map[string]interface{}) {
 			"available": isMultiTenant,
 		})
 
+	case "GetRoutingTable":
+		driver := b.drivers[data["driverId"].(string)]
+		database := ""
+		if data["database"] != nil {
+			database = data["database"].(string)
+		}
+		table, err := driver.RoutingTable(context.Background(), database)
+		if err != nil {
+			b.writeError(err)
+			return
+		}
+		b.writeResponse("RoutingTable", map[string]interface{}{
+			"database": table.DatabaseName,
+			"ttl":      int(table.TimeToLive.Seconds()),
+			"routers":  table.Routers,
+			"readers":  table.Readers,
+			"writers":  table.Writers,
+		})
+
+	case "ForcedRoutingTableUpdate":
+		driverId := data["driverId"].(string)
+		driver := b.drivers[driverId]
+		database := ""
+		if data["database"] != nil {
+			database = data["database"].(string)
+		}
+		var bookmarks []string
+		if data["bookmarks"] != nil {
+			for _, x := range data["bookmarks"].([]interface{}) {
+				bookmarks = append(bookmarks, x.(string))
+			}
+		}
+		_, err := driver.ForceRoutingTableUpdate(context.Background(), database, bookmarks...)
+		if err != nil {
+			b.writeError(err)
+			return
+		}
+		b.writeResponse("Driver", map[string]interface{}{"id": driverId})
+
 	case "GetFeatures":
 		b.writeResponse("FeatureList", map[string]interface{}{
 			"features": []string{
+				"Backend:RTFetch",
+				"Backend:RTForceUpdate",
 				"ConfHint:connection.recv_timeout_seconds",
 				"Feature:API:Liveness.Check",
 				"Feature:API:Type.Temporal",
@@ -910,7 +952,6 @@ func testSkips() map[string]string {
 		"stub.routing.test_routing_v4x1.RoutingV4x1.test_should_revert_to_initial_router_if_known_router_throws_protocol_errors": "It needs investigation - custom resolver does not seem to be called",
 		"stub.routing.test_routing_v4x3.RoutingV4x3.test_should_revert_to_initial_router_if_known_router_throws_protocol_errors": "It needs investigation - custom resolver does not seem to be called",
 		"stub.routing.test_routing_v4x4.RoutingV4x4.test_should_revert_to_initial_router_if_known_router_throws_protocol_errors": "It needs investigation - custom resolver does not seem to be called",
-		"stub.configuration_hints.test_connection_recv_timeout_seconds.TestRoutingConnectionRecvTimeout.*":                       "No GetRoutingTable support - too tricky to implement in Go",
 		"stub.homedb.test_homedb.TestHomeDb.test_session_should_cache_home_db_despite_new_rt":                                    "Driver does not remove servers from RT when connection breaks.",
 		"neo4j.test_authentication.TestAuthenticationBasic.test_error_on_incorrect_credentials_tx":                               "Driver retries tx on failed authentication.",
 		"stub.iteration.test_result_scope.TestResultScope.*":                                                                     "Results are always valid but don't return records when out of scope",